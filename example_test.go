@@ -57,3 +57,179 @@ func ExampleTq_Validate() {
 	//             ^
 	// Parser error: expected ']' to terminate selector; got '['
 }
+
+// ExampleTq_Run_comma shows how the comma operator produces multiple outputs
+// from a single query so that the input data gets decoded only once.
+func ExampleTq_Run_comma() {
+	input := strings.NewReader(`
+[server]
+host = "10.0.0.1"
+port = 8080
+`)
+	var output bytes.Buffer
+	query := ".server | .host, .port"
+	config := toml.GoTOMLConf{}
+	goToml := toml.NewGoTOML(config)
+	adapter := toml.NewAdapter(goToml)
+	tq := tq.New(adapter)
+	_ = tq.Run(input, &output, query)
+	fmt.Println(output.String())
+	// Output:
+	// 10.0.0.1
+	// 8080
+}
+
+// ExampleTq_Run_select shows how select picks out the tables that satisfy the
+// condition.
+func ExampleTq_Run_select() {
+	input := strings.NewReader(`
+[servers.alpha]
+ip = "10.0.0.1"
+role = "frontend"
+
+[servers.beta]
+ip = "10.0.0.2"
+role = "backend"
+`)
+	var output bytes.Buffer
+	query := `.servers[] | select(.role == "backend") | .ip`
+	config := toml.GoTOMLConf{}
+	goToml := toml.NewGoTOML(config)
+	adapter := toml.NewAdapter(goToml)
+	tq := tq.New(adapter)
+	_ = tq.Run(input, &output, query)
+	fmt.Println(output.String())
+	// Output:
+	// 10.0.0.2
+}
+
+// ExampleTq_Run_object shows how to reshape tables with the object
+// construction.
+func ExampleTq_Run_object() {
+	input := strings.NewReader(`
+[[servers]]
+hostname = "alpha"
+ip = "10.0.0.1"
+`)
+	var output bytes.Buffer
+	query := ".servers[] | {name: .hostname, addr: .ip}"
+	config := toml.GoTOMLConf{}
+	goToml := toml.NewGoTOML(config)
+	adapter := toml.NewAdapter(goToml)
+	tq := tq.New(adapter)
+	_ = tq.Run(input, &output, query)
+	fmt.Println(output.String())
+	// Output:
+	// addr = '10.0.0.1'
+	// name = 'alpha'
+}
+
+// ExampleTq_Run_variables shows how to refer to a value from the outer table
+// with a variable binding.
+func ExampleTq_Run_variables() {
+	input := strings.NewReader(`
+[owner]
+name = "ops"
+
+[[servers]]
+ip = "10.0.0.1"
+`)
+	var output bytes.Buffer
+	query := ".owner.name as $o | .servers[] | {server: .ip, owner: $o}"
+	config := toml.GoTOMLConf{}
+	goToml := toml.NewGoTOML(config)
+	adapter := toml.NewAdapter(goToml)
+	tq := tq.New(adapter)
+	_ = tq.Run(input, &output, query)
+	fmt.Println(output.String())
+	// Output:
+	// owner = 'ops'
+	// server = '10.0.0.1'
+}
+
+// ExampleTq_Run_definition shows how to define a function to reuse a part of
+// the query.
+func ExampleTq_Run_definition() {
+	input := strings.NewReader(`
+[[servers]]
+ip = "10.0.0.1"
+
+[[servers]]
+ip = "10.0.0.2"
+`)
+	var output bytes.Buffer
+	query := `def addr($port): "\(.ip):\($port)"; .servers[] | addr(80)`
+	config := toml.GoTOMLConf{}
+	goToml := toml.NewGoTOML(config)
+	adapter := toml.NewAdapter(goToml)
+	tq := tq.New(adapter)
+	_ = tq.Run(input, &output, query)
+	fmt.Println(output.String())
+	// Output:
+	// 10.0.0.1:80
+	// 10.0.0.2:80
+}
+
+// ExampleTq_Run_reduce shows how to fold an array of tables into a lookup
+// table with the reduction.
+func ExampleTq_Run_reduce() {
+	input := strings.NewReader(`
+[[backend]]
+name = "alpha"
+weight = 2
+
+[[backend]]
+name = "beta"
+weight = 3
+`)
+	var output bytes.Buffer
+	query := "reduce .backend[] as {$name, $weight} ({}; . + {($name): $weight})"
+	config := toml.GoTOMLConf{}
+	goToml := toml.NewGoTOML(config)
+	adapter := toml.NewAdapter(goToml)
+	tq := tq.New(adapter)
+	_ = tq.Run(input, &output, query)
+	fmt.Println(output.String())
+	// Output:
+	// alpha = 2
+	// beta = 3
+}
+
+// ExampleTq_Run_interpolation shows how to build strings from values of
+// tables with string interpolation.
+func ExampleTq_Run_interpolation() {
+	input := strings.NewReader(`
+[database]
+host = "db.local"
+port = 5432
+`)
+	var output bytes.Buffer
+	query := `.database | "postgres://\(.host):\(.port)/app"`
+	config := toml.GoTOMLConf{}
+	goToml := toml.NewGoTOML(config)
+	adapter := toml.NewAdapter(goToml)
+	tq := tq.New(adapter)
+	_ = tq.Run(input, &output, query)
+	fmt.Println(output.String())
+	// Output:
+	// postgres://db.local:5432/app
+}
+
+// ExampleTq_Run_format shows how to quote values for the shell with the @sh
+// format string.
+func ExampleTq_Run_format() {
+	input := strings.NewReader(`
+[backup]
+paths = ["/srv/my files", "/etc/it's.conf"]
+`)
+	var output bytes.Buffer
+	query := `.backup | @sh "tar -czf backup.tgz \(.paths)"`
+	config := toml.GoTOMLConf{}
+	goToml := toml.NewGoTOML(config)
+	adapter := toml.NewAdapter(goToml)
+	tq := tq.New(adapter)
+	_ = tq.Run(input, &output, query)
+	fmt.Println(output.String())
+	// Output:
+	// tar -czf backup.tgz '/srv/my files' '/etc/it'\''s.conf'
+}
@@ -28,12 +28,168 @@ type Query struct {
 	Filters []Expr
 }
 
+// Pipe represents a binary pipe expression. The output of the left-hand side
+// expression is fed as the input to the right-hand side expression.
+type Pipe struct {
+	Left, Right Expr
+}
+
+// Comma represents a binary comma expression. Both the left-hand side and the
+// right-hand side expression are run against the same input, and their
+// outputs are concatenated in this order.
+type Comma struct {
+	Left, Right Expr
+}
+
+// Binary represents a binary operator expression such as a comparison. Both
+// operand expressions are run against the same input, and the operator is
+// applied to each pair of their outputs.
+type Binary struct {
+	Left     Expr
+	Operator string
+	Right    Expr
+}
+
+// Negation represents the unary minus applied to every output of the
+// expression run against the input data.
+type Negation struct {
+	Value Expr
+}
+
+// Logical represents a short-circuiting boolean operator expression. The
+// right-hand side expression is run only if the output of the left-hand side
+// expression does not determine the result on its own.
+type Logical struct {
+	Left     Expr
+	Operator string
+	Right    Expr
+}
+
+// Call represents a call of the function with the given name. Argument
+// expressions are passed to the function unevaluated, so the function decides
+// what input data to run them against. Root tells if the call opens the root
+// query, where the name of an undefined function without arguments selects the
+// key of the input table instead.
+type Call struct {
+	Name string
+	Args []Expr
+	Root bool
+}
+
+// Definition represents the definition of the function with the given name
+// and parameters. Parameters starting with $ take values, and the others take
+// filters. The function is visible in its own body and in the rest
+// expression, which runs against the input data.
+type Definition struct {
+	Name   string
+	Params []string
+	Body   Expr
+	Rest   Expr
+}
+
+// Object represents the construction of a table from its entries. Each entry
+// contributes a single key-value pair, and entries whose key or value
+// expression produces multiple outputs produce multiple tables.
+type Object struct {
+	Entries []ObjectEntry
+}
+
+// ObjectEntry represents a single entry of the constructed table. Both the key
+// and the value expression are run against the input data.
+type ObjectEntry struct {
+	Key, Value Expr
+}
+
+// Array represents the construction of an array collecting all outputs of the
+// expression run against the input data. The empty array has no expression.
+type Array struct {
+	Value Expr
+}
+
+// Literal represents a constant value that replaces the input data.
+type Literal struct {
+	Value any
+}
+
+// Interpolation represents a quoted string with interpolated expressions. Its
+// parts are string literals and expressions run against the input data whose
+// outputs are converted to strings. Every combination of these outputs
+// produces another string. Outputs are converted with the named format, and
+// the empty name stands for the plain text conversion.
+type Interpolation struct {
+	Format string
+	Parts  []Expr
+}
+
+// Format represents the format string that renders the input data as a
+// string in the named format such as csv or base64.
+type Format struct {
+	Name string
+}
+
+// Binding represents the binding of every output of the source expression to
+// the pattern. The body expression runs against the input data for each of
+// these outputs with variables of the pattern in scope. Further patterns are
+// alternatives tried in turn when the previous one does not match the output.
+type Binding struct {
+	Source   Expr
+	Patterns []Expr
+	Body     Expr
+}
+
+// Reduce represents the reduction of outputs of the source expression bound to
+// the patterns one by one. The update expression runs against the state with
+// variables of the pattern in scope, and its output becomes the next state.
+// The initial state is the output of the init expression.
+type Reduce struct {
+	Source   Expr
+	Patterns []Expr
+	Init     Expr
+	Update   Expr
+}
+
+// Foreach represents the reduction that produces intermediate states. The
+// extract expression runs against every state the update expression produces,
+// and it passes the state through when it is not given.
+type Foreach struct {
+	Source   Expr
+	Patterns []Expr
+	Init     Expr
+	Update   Expr
+	Extract  Expr
+}
+
+// ArrayPattern represents the pattern destructuring the array. Its elements
+// are patterns matching elements of the array at the same index.
+type ArrayPattern struct {
+	Elements []Expr
+}
+
+// ObjectPattern represents the pattern destructuring the table. The value
+// pattern of each entry matches the value of the table under the key. The
+// variable standing for the key binds the value under the key of its name.
+type ObjectPattern struct {
+	Entries []ObjectEntry
+}
+
+// Variable represents the reference to the value bound to the variable of the
+// given name.
+type Variable struct {
+	Name string
+}
+
 // Filter stands for a single tq filter. It the fundamental building block of
 // the tq query.
 type Filter struct {
 	Kind Expr
 }
 
+// Optional represents a filter whose data type errors are suppressed. Input
+// data that cannot be queried with the wrapped filter produces no output.
+type Optional struct {
+	Value Expr
+}
+
 // Identity specifies the identity data transformation that returns the
 // filtered data argument unchanged.
 type Identity struct{}
@@ -43,15 +199,21 @@ type Selector struct {
 	Value Expr
 }
 
-// Span represents a filter that takes a slice of a list-like sequence.
+// Span represents a filter that takes a slice of a list-like sequence. The
+// optional step specifies the stride between consecutive elements of the
+// slice. A negative step takes the elements in the reverse order.
 type Span struct {
-	Left, Right *Integer
+	Left, Right, Step *Integer
 }
 
 // Iterator represents a sequeced iterator. The implementation of the iterator
 // for TOML data types is to be provided by the visiting interpreter.
 type Iterator struct{}
 
+// Recurse represents the recursive descent filter. It yields the input data
+// followed by every value nested in it.
+type Recurse struct{}
+
 // String represents the key selector that can be used, for instance, in a form
 // of dictionary lookup.
 type String struct {
@@ -84,6 +246,189 @@ func (*Query) String() string {
 	return "query"
 }
 
+// Accept implements the Expr interface for the visitor design pattern.
+func (p *Pipe) Accept(v Visitor) {
+	v.VisitPipe(p)
+}
+
+// String provides the string representation of the AST expression.
+func (*Pipe) String() string {
+	return "pipe"
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (c *Comma) Accept(v Visitor) {
+	v.VisitComma(c)
+}
+
+// String provides the string representation of the AST expression.
+func (*Comma) String() string {
+	return "comma"
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (b *Binary) Accept(v Visitor) {
+	v.VisitBinary(b)
+}
+
+// String provides the string representation of the AST expression.
+func (b *Binary) String() string {
+	return fmt.Sprintf("binary %s", b.Operator)
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (n *Negation) Accept(v Visitor) {
+	v.VisitNegation(n)
+}
+
+// String provides the string representation of the AST expression.
+func (*Negation) String() string {
+	return "negation"
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (l *Logical) Accept(v Visitor) {
+	v.VisitLogical(l)
+}
+
+// String provides the string representation of the AST expression.
+func (l *Logical) String() string {
+	return fmt.Sprintf("logical %s", l.Operator)
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (c *Call) Accept(v Visitor) {
+	v.VisitCall(c)
+}
+
+// String provides the string representation of the AST expression.
+func (c *Call) String() string {
+	return fmt.Sprintf("call %s/%d", c.Name, len(c.Args))
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (d *Definition) Accept(v Visitor) {
+	v.VisitDefinition(d)
+}
+
+// String provides the string representation of the AST expression.
+func (d *Definition) String() string {
+	return fmt.Sprintf("definition %s/%d", d.Name, len(d.Params))
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (o *Object) Accept(v Visitor) {
+	v.VisitObject(o)
+}
+
+// String provides the string representation of the AST expression.
+func (*Object) String() string {
+	return "object"
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (a *Array) Accept(v Visitor) {
+	v.VisitArray(a)
+}
+
+// String provides the string representation of the AST expression.
+func (*Array) String() string {
+	return "array"
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (l *Literal) Accept(v Visitor) {
+	v.VisitLiteral(l)
+}
+
+// String provides the string representation of the AST expression.
+func (l *Literal) String() string {
+	if s, ok := l.Value.(string); ok {
+		return fmt.Sprintf("literal %q", s)
+	}
+	return fmt.Sprintf("literal %v", l.Value)
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (i *Interpolation) Accept(v Visitor) {
+	v.VisitInterpolation(i)
+}
+
+// String provides the string representation of the AST expression.
+func (*Interpolation) String() string {
+	return "interpolation"
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (f *Format) Accept(v Visitor) {
+	v.VisitFormat(f)
+}
+
+// String provides the string representation of the AST expression.
+func (f *Format) String() string {
+	return "format @" + f.Name
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (b *Binding) Accept(v Visitor) {
+	v.VisitBinding(b)
+}
+
+// String provides the string representation of the AST expression.
+func (*Binding) String() string {
+	return "binding"
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (r *Reduce) Accept(v Visitor) {
+	v.VisitReduce(r)
+}
+
+// String provides the string representation of the AST expression.
+func (*Reduce) String() string {
+	return "reduce"
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (f *Foreach) Accept(v Visitor) {
+	v.VisitForeach(f)
+}
+
+// String provides the string representation of the AST expression.
+func (*Foreach) String() string {
+	return "foreach"
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (a *ArrayPattern) Accept(v Visitor) {
+	v.VisitArrayPattern(a)
+}
+
+// String provides the string representation of the AST expression.
+func (*ArrayPattern) String() string {
+	return "array pattern"
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (o *ObjectPattern) Accept(v Visitor) {
+	v.VisitObjectPattern(o)
+}
+
+// String provides the string representation of the AST expression.
+func (*ObjectPattern) String() string {
+	return "object pattern"
+}
+
+// Accept implements the Expr interface for the visitor design pattern.
+func (r *Variable) Accept(v Visitor) {
+	v.VisitVariable(r)
+}
+
+// String provides the string representation of the AST expression.
+func (r *Variable) String() string {
+	return "variable $" + r.Name
+}
+
 // Accept implements the Expr interface for the visitor design pattern.
 func (f *Filter) Accept(v Visitor) {
 	v.VisitFilter(f)
@@ -94,6 +439,16 @@ func (*Filter) String() string {
 	return "filter"
 }
 
+// Accept implements the Expr interface for the visitor design pattern.
+func (o *Optional) Accept(v Visitor) {
+	v.VisitOptional(o)
+}
+
+// String provides the string representation of the AST expression.
+func (*Optional) String() string {
+	return "optional"
+}
+
 // Accept implements the Expr interface for the visitor design pattern.
 func (i *Identity) Accept(v Visitor) {
 	v.VisitIdentity(i)
@@ -128,6 +483,9 @@ func (s *Span) String() string {
 	if s.Right != nil {
 		r = s.Right.Value
 	}
+	if s.Step != nil {
+		return fmt.Sprintf("span [%s:%s:%s]", l, r, s.Step.Value)
+	}
 	return fmt.Sprintf("span [%s:%s]", l, r)
 }
 
@@ -141,6 +499,11 @@ func (s *Span) GetRight(def int) int {
 	return s.asInt(s.Right, def)
 }
 
+// GetStep returns the value of the step expression node of the Span.
+func (s *Span) GetStep(def int) int {
+	return s.asInt(s.Step, def)
+}
+
 func (s *Span) asInt(i *Integer, def int) int {
 	var result = def
 	if i != nil {
@@ -163,6 +526,16 @@ func (i *Iterator) String() string {
 	return "iterator"
 }
 
+// Accept implements the Expr interface for the visitor design pattern.
+func (r *Recurse) Accept(v Visitor) {
+	v.VisitRecurse(r)
+}
+
+// String provides the string representation of the AST expression.
+func (*Recurse) String() string {
+	return "recurse"
+}
+
 // Accept implements the Expr interface for the visitor design pattern.
 func (s *String) Accept(v Visitor) {
 	v.VisitString(s)
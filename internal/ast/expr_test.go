@@ -7,15 +7,35 @@ import (
 
 type mockVisitor struct{}
 
-func (mockVisitor) VisitRoot(e Expr)     {}
-func (mockVisitor) VisitQuery(e Expr)    {}
-func (mockVisitor) VisitFilter(e Expr)   {}
-func (mockVisitor) VisitIdentity(e Expr) {}
-func (mockVisitor) VisitSelector(e Expr) {}
-func (mockVisitor) VisitIterator(e Expr) {}
-func (mockVisitor) VisitSpan(e Expr)     {}
-func (mockVisitor) VisitString(e Expr)   {}
-func (mockVisitor) VisitInteger(e Expr)  {}
+func (mockVisitor) VisitRoot(e Expr)          {}
+func (mockVisitor) VisitQuery(e Expr)         {}
+func (mockVisitor) VisitPipe(e Expr)          {}
+func (mockVisitor) VisitComma(e Expr)         {}
+func (mockVisitor) VisitBinary(e Expr)        {}
+func (mockVisitor) VisitNegation(e Expr)      {}
+func (mockVisitor) VisitLogical(e Expr)       {}
+func (mockVisitor) VisitCall(e Expr)          {}
+func (mockVisitor) VisitDefinition(e Expr)    {}
+func (mockVisitor) VisitObject(e Expr)        {}
+func (mockVisitor) VisitArray(e Expr)         {}
+func (mockVisitor) VisitLiteral(e Expr)       {}
+func (mockVisitor) VisitInterpolation(e Expr) {}
+func (mockVisitor) VisitFormat(e Expr)        {}
+func (mockVisitor) VisitBinding(e Expr)       {}
+func (mockVisitor) VisitReduce(e Expr)        {}
+func (mockVisitor) VisitForeach(e Expr)       {}
+func (mockVisitor) VisitArrayPattern(e Expr)  {}
+func (mockVisitor) VisitObjectPattern(e Expr) {}
+func (mockVisitor) VisitVariable(e Expr)      {}
+func (mockVisitor) VisitFilter(e Expr)        {}
+func (mockVisitor) VisitOptional(e Expr)      {}
+func (mockVisitor) VisitIdentity(e Expr)      {}
+func (mockVisitor) VisitSelector(e Expr)      {}
+func (mockVisitor) VisitIterator(e Expr)      {}
+func (mockVisitor) VisitSpan(e Expr)          {}
+func (mockVisitor) VisitRecurse(e Expr)       {}
+func (mockVisitor) VisitString(e Expr)        {}
+func (mockVisitor) VisitInteger(e Expr)       {}
 
 // Test the Expr Accept public method required by the visitor design pattern.
 func TestExprAccept(t *testing.T) {
@@ -25,11 +45,31 @@ func TestExprAccept(t *testing.T) {
 	}{
 		{"root", &Root{}},
 		{"query", &Query{}},
+		{"pipe", &Pipe{}},
+		{"comma", &Comma{}},
+		{"binary", &Binary{}},
+		{"negation", &Negation{}},
+		{"logical", &Logical{}},
+		{"call", &Call{}},
+		{"definition", &Definition{}},
+		{"object", &Object{}},
+		{"array", &Array{}},
+		{"literal", &Literal{}},
+		{"interpolation", &Interpolation{}},
+		{"format", &Format{}},
+		{"binding", &Binding{}},
+		{"reduce", &Reduce{}},
+		{"foreach", &Foreach{}},
+		{"array pattern", &ArrayPattern{}},
+		{"object pattern", &ObjectPattern{}},
+		{"variable", &Variable{}},
 		{"filter", &Filter{}},
+		{"optional", &Optional{}},
 		{"identity", &Identity{}},
 		{"selector", &Selector{}},
 		{"iterator", &Iterator{}},
 		{"span", &Span{}},
+		{"recurse", &Recurse{}},
 		{"string", &String{}},
 		{"integer", &Integer{}},
 	}
@@ -50,7 +90,28 @@ func TestExprString(t *testing.T) {
 	}{
 		{"root", &Root{}, "root"},
 		{"query", &Query{}, "query"},
+		{"pipe", &Pipe{}, "pipe"},
+		{"comma", &Comma{}, "comma"},
+		{"binary", &Binary{Operator: "=="}, "binary =="},
+		{"negation", &Negation{}, "negation"},
+		{"logical", &Logical{Operator: "and"}, "logical and"},
+		{"call", &Call{Name: "length"}, "call length/0"},
+		{"call", &Call{Name: "has", Args: []Expr{&Literal{Value: "a"}}}, "call has/1"},
+		{"definition", &Definition{Name: "f", Params: []string{"g", "$x"}}, "definition f/2"},
+		{"object", &Object{}, "object"},
+		{"array", &Array{}, "array"},
+		{"interpolation", &Interpolation{}, "interpolation"},
+		{"format", &Format{Name: "csv"}, "format @csv"},
+		{"binding", &Binding{}, "binding"},
+		{"reduce", &Reduce{}, "reduce"},
+		{"foreach", &Foreach{}, "foreach"},
+		{"array pattern", &ArrayPattern{}, "array pattern"},
+		{"object pattern", &ObjectPattern{}, "object pattern"},
+		{"variable", &Variable{Name: "x"}, "variable $x"},
+		{"literal", &Literal{Value: "backend"}, "literal \"backend\""},
+		{"literal", &Literal{Value: int64(8080)}, "literal 8080"},
 		{"filter", &Filter{}, "filter"},
+		{"optional", &Optional{}, "optional"},
 		{"identity", &Identity{}, "identity"},
 		{"selector", &Selector{}, "selector"},
 		{"iterator", &Iterator{}, "iterator"},
@@ -58,6 +119,10 @@ func TestExprString(t *testing.T) {
 		{"span", &Span{Left: &Integer{"2"}}, "span [2:]"},
 		{"span", &Span{Right: &Integer{"10"}}, "span [:10]"},
 		{"span", &Span{Left: &Integer{"0"}, Right: &Integer{"99"}}, "span [0:99]"},
+		{"span", &Span{Left: &Integer{"-3"}}, "span [-3:]"},
+		{"span", &Span{Step: &Integer{"-1"}}, "span [::-1]"},
+		{"span", &Span{Left: &Integer{"1"}, Right: &Integer{"9"}, Step: &Integer{"2"}}, "span [1:9:2]"},
+		{"recurse", &Recurse{}, "recurse"},
 		{"string", &String{"programmers"}, "string \"programmers\""},
 		{"string", &String{}, "string \"\""},
 		{"integer", &Integer{}, "integer "},
@@ -156,6 +221,48 @@ func TestSpanGetRight(t *testing.T) {
 	}
 }
 
+// Verify if step int value of the span is retrieved.
+func TestSpanGetStep(t *testing.T) {
+	cases := []struct {
+		name     string
+		intValue *Integer
+		want     int
+	}{
+		{
+			name:     "2",
+			intValue: &Integer{Value: "2"},
+			want:     2,
+		},
+		{
+			name:     "-1",
+			intValue: &Integer{Value: "-1"},
+			want:     -1,
+		},
+		{
+			// nil Integer pointer results in the default def value returned
+			name:     "nil-integer",
+			intValue: nil,
+			want:     1,
+		},
+		{
+			// non-convertable string results in the default def value returned
+			name:     "non-convertable",
+			intValue: &Integer{Value: "non-convertable"},
+			want:     1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := Span{Step: c.intValue}
+			def := 1
+			have := s.GetStep(def)
+			if have != c.want {
+				t.Errorf("have: %d; want: %d", have, c.want)
+			}
+		})
+	}
+}
+
 // Check the string integer value conversion to the proper integer.
 func TestIntegerVtoi(t *testing.T) {
 	cases := []struct {
@@ -167,6 +274,8 @@ func TestIntegerVtoi(t *testing.T) {
 		{"12", 12},
 		{"67", 67},
 		{"99", 99},
+		{"-1", -1},
+		{"-30", -30},
 	}
 	for _, c := range cases {
 		t.Run(c.value, func(t *testing.T) {
@@ -5,11 +5,31 @@ package ast
 type Visitor interface {
 	VisitRoot(Expr)
 	VisitQuery(Expr)
+	VisitPipe(Expr)
+	VisitComma(Expr)
+	VisitBinary(Expr)
+	VisitNegation(Expr)
+	VisitLogical(Expr)
+	VisitCall(Expr)
+	VisitDefinition(Expr)
+	VisitObject(Expr)
+	VisitArray(Expr)
+	VisitLiteral(Expr)
+	VisitInterpolation(Expr)
+	VisitFormat(Expr)
+	VisitBinding(Expr)
+	VisitReduce(Expr)
+	VisitForeach(Expr)
+	VisitArrayPattern(Expr)
+	VisitObjectPattern(Expr)
+	VisitVariable(Expr)
 	VisitFilter(Expr)
+	VisitOptional(Expr)
 	VisitIdentity(Expr)
 	VisitSelector(Expr)
 	VisitIterator(Expr)
 	VisitSpan(Expr)
+	VisitRecurse(Expr)
 	VisitString(Expr)
 	VisitInteger(Expr)
 }
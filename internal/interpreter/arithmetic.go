@@ -0,0 +1,139 @@
+package interpreter
+
+import (
+	"maps"
+	"math"
+)
+
+// add adds the operand r to the operand l. Numbers are added together, strings
+// and arrays are concatenated, and tables are merged with the keys of r taking
+// precedence over the keys of l.
+func add(l, r any) (any, error) {
+	switch a := l.(type) {
+	case string:
+		if b, ok := r.(string); ok {
+			return a + b, nil
+		}
+	case []any:
+		if b, ok := r.([]any); ok {
+			result := make([]any, 0, len(a)+len(b))
+			return append(append(result, a...), b...), nil
+		}
+	case map[string]any:
+		if b, ok := r.(map[string]any); ok {
+			result := maps.Clone(a)
+			maps.Copy(result, b)
+			return result, nil
+		}
+	}
+	return arithmetic(l, r, addInts, func(a, b float64) float64 { return a + b })
+}
+
+// subtract subtracts the number r from the number l.
+func subtract(l, r any) (any, error) {
+	return arithmetic(l, r, subtractInts, func(a, b float64) float64 { return a - b })
+}
+
+// negate returns the number v with its sign reversed.
+func negate(v any) (any, error) {
+	switch n := v.(type) {
+	case int64:
+		if n == math.MinInt64 {
+			return nil, ErrIntegerOverflow
+		}
+		return -n, nil
+	case float64:
+		return -n, nil
+	}
+	return nil, ErrTOMLDataType
+}
+
+// multiply multiplies the number l by the number r.
+func multiply(l, r any) (any, error) {
+	return arithmetic(l, r, multiplyInts, func(a, b float64) float64 { return a * b })
+}
+
+// divide divides the number l by the number r. The quotient of two integers is
+// an integer if the division leaves no remainder and a float otherwise.
+func divide(l, r any) (any, error) {
+	return arithmetic(l, r, divideInts, func(a, b float64) float64 { return a / b })
+}
+
+// modulo returns the remainder of dividing the number l by the number r. The
+// remainder has the sign of l.
+func modulo(l, r any) (any, error) {
+	return arithmetic(l, r, moduloInts, math.Mod)
+}
+
+// arithmetic applies the integer operation ints when both l and r are integers
+// and the float operation floats when at least one of them is a float.
+func arithmetic(
+	l, r any,
+	ints func(a, b int64) (any, error),
+	floats func(a, b float64) float64,
+) (any, error) {
+	switch a := l.(type) {
+	case int64:
+		switch b := r.(type) {
+		case int64:
+			return ints(a, b)
+		case float64:
+			return floats(float64(a), b), nil
+		}
+	case float64:
+		switch b := r.(type) {
+		case int64:
+			return floats(a, float64(b)), nil
+		case float64:
+			return floats(a, b), nil
+		}
+	}
+	return nil, ErrTOMLDataType
+}
+
+func addInts(a, b int64) (any, error) {
+	c := a + b
+	if (c > a) != (b > 0) {
+		return nil, ErrIntegerOverflow
+	}
+	return c, nil
+}
+
+func subtractInts(a, b int64) (any, error) {
+	c := a - b
+	if (c < a) != (b > 0) {
+		return nil, ErrIntegerOverflow
+	}
+	return c, nil
+}
+
+func multiplyInts(a, b int64) (any, error) {
+	if a == 0 || b == 0 {
+		return int64(0), nil
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return nil, ErrIntegerOverflow
+	}
+	return c, nil
+}
+
+func divideInts(a, b int64) (any, error) {
+	switch {
+	case b == 0:
+		return nil, ErrDivisionByZero
+	case a == math.MinInt64 && b == -1:
+		return nil, ErrIntegerOverflow
+	case a%b != 0:
+		return float64(a) / float64(b), nil
+	default:
+		return a / b, nil
+	}
+}
+
+func moduloInts(a, b int64) (any, error) {
+	if b == 0 {
+		return nil, ErrDivisionByZero
+	}
+	return a % b, nil
+}
@@ -0,0 +1,124 @@
+package interpreter
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// Test arithmetic operators on values of TOML data types.
+func TestArithmetic(t *testing.T) {
+	cases := []struct {
+		name string
+		op   func(l, r any) (any, error)
+		l, r any
+		want any
+	}{
+		{"add-ints", add, int64(2), int64(3), int64(5)},
+		{"add-int-float", add, int64(2), 0.5, 2.5},
+		{"add-float-int", add, 0.5, int64(2), 2.5},
+		{"add-inf", add, math.Inf(1), int64(1), math.Inf(1)},
+		{"add-strings", add, "/etc/", "tq", "/etc/tq"},
+		{"add-arrays", add, []any{int64(1)}, []any{"a"}, []any{int64(1), "a"}},
+		{
+			"add-tables",
+			add,
+			map[string]any{"host": "a", "port": int64(80)},
+			map[string]any{"port": int64(8080)},
+			map[string]any{"host": "a", "port": int64(8080)},
+		},
+		{"subtract-ints", subtract, int64(2), int64(3), int64(-1)},
+		{"subtract-floats", subtract, 2.5, 0.5, 2.0},
+		{"multiply-ints", multiply, int64(-4), int64(3), int64(-12)},
+		{"multiply-zero", multiply, int64(0), int64(math.MinInt64), int64(0)},
+		{"multiply-int-float", multiply, int64(3), 0.5, 1.5},
+		{"divide-exact", divide, int64(12), int64(4), int64(3)},
+		{"divide-inexact", divide, int64(7), int64(2), 3.5},
+		{"divide-float-zero", divide, 1.0, int64(0), math.Inf(1)},
+		{"modulo-ints", modulo, int64(-7), int64(3), int64(-1)},
+		{"modulo-min", modulo, int64(math.MinInt64), int64(-1), int64(0)},
+		{"modulo-floats", modulo, 7.5, int64(2), 1.5},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			have, err := c.op(c.l, c.r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Check if NaN propagates through arithmetic on floats.
+func TestArithmeticNaN(t *testing.T) {
+	have, err := add(math.NaN(), int64(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := have.(float64); !ok || !math.IsNaN(f) {
+		t.Errorf("have: %v; want: NaN", have)
+	}
+}
+
+// Test the unary minus against numbers and values that cannot be negated.
+func TestNegate(t *testing.T) {
+	cases := []struct {
+		name string
+		v    any
+		want any
+		err  error
+	}{
+		{"int", int64(3), int64(-3), nil},
+		{"max", int64(math.MaxInt64), int64(-math.MaxInt64), nil},
+		{"float", -0.5, 0.5, nil},
+		{"inf", math.Inf(1), math.Inf(-1), nil},
+		{"min", int64(math.MinInt64), nil, ErrIntegerOverflow},
+		{"string", "a", nil, ErrTOMLDataType},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			have, err := negate(c.v)
+			if !errors.Is(err, c.err) {
+				t.Errorf("have: %v; want: %v", err, c.err)
+			}
+			if have != c.want {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if arithmetic operators report the errors that they run into.
+func TestArithmeticError(t *testing.T) {
+	cases := []struct {
+		name string
+		op   func(l, r any) (any, error)
+		l, r any
+		want error
+	}{
+		{"add-overflow", add, int64(math.MaxInt64), int64(1), ErrIntegerOverflow},
+		{"add-underflow", add, int64(math.MinInt64), int64(-1), ErrIntegerOverflow},
+		{"add-mixed", add, "port", int64(1), ErrTOMLDataType},
+		{"add-array-table", add, []any{}, map[string]any{}, ErrTOMLDataType},
+		{"subtract-overflow", subtract, int64(math.MinInt64), int64(1), ErrIntegerOverflow},
+		{"subtract-strings", subtract, "a", "b", ErrTOMLDataType},
+		{"multiply-overflow", multiply, int64(math.MaxInt64), int64(2), ErrIntegerOverflow},
+		{"multiply-min", multiply, int64(-1), int64(math.MinInt64), ErrIntegerOverflow},
+		{"multiply-bools", multiply, true, true, ErrTOMLDataType},
+		{"divide-zero", divide, int64(1), int64(0), ErrDivisionByZero},
+		{"divide-overflow", divide, int64(math.MinInt64), int64(-1), ErrIntegerOverflow},
+		{"modulo-zero", modulo, int64(1), int64(0), ErrDivisionByZero},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := c.op(c.l, c.r)
+			if !errors.Is(err, c.want) {
+				t.Errorf("have: %v; want: %v", err, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,776 @@
+package interpreter
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+// builtin is a function that can be called by its name from the query. It runs
+// against a single input value, and it receives its arguments as streams to
+// run against the input value as needed.
+type builtin func(data any, args []stream, emit emitter) error
+
+// builtins maps function signatures onto builtin functions.
+var builtins = map[string]builtin{
+	"not/0":            not,
+	"select/1":         selection,
+	"length/0":         length,
+	"keys/0":           keys,
+	"keys_unsorted/0":  keys,
+	"values/0":         values,
+	"has/1":            has,
+	"in/1":             in,
+	"type/0":           typeOf,
+	"map/1":            mapping,
+	"map_values/1":     mapValues,
+	"walk/1":           walk,
+	"sort/0":           sorting,
+	"sort_by/1":        sortBy,
+	"group_by/1":       groupBy,
+	"unique/0":         unique,
+	"unique_by/1":      uniqueBy,
+	"min_by/1":         minBy,
+	"max_by/1":         maxBy,
+	"add/0":            sum,
+	"min/0":            minimum,
+	"max/0":            maximum,
+	"any/0":            anyOf,
+	"any/1":            anyOf,
+	"any/2":            anyOf,
+	"all/0":            allOf,
+	"all/1":            allOf,
+	"all/2":            allOf,
+	"flatten/0":        flatten,
+	"flatten/1":        flatten,
+	"range/1":          span,
+	"range/2":          span,
+	"range/3":          span,
+	"first/0":          first,
+	"first/1":          first,
+	"last/0":           last,
+	"last/1":           last,
+	"limit/2":          limit,
+	"nth/1":            nth,
+	"nth/2":            nth,
+	"split/1":          split,
+	"join/1":           join,
+	"ascii_downcase/0": downcase,
+	"ascii_upcase/0":   upcase,
+	"ltrimstr/1":       ltrimstr,
+	"rtrimstr/1":       rtrimstr,
+	"trim/0":           trim,
+	"ltrim/0":          ltrim,
+	"rtrim/0":          rtrim,
+	"startswith/1":     startsWith,
+	"endswith/1":       endsWith,
+	"contains/1":       containing,
+	"indices/1":        indices,
+	"index/1":          index,
+	"rindex/1":         rindex,
+	"explode/0":        explode,
+	"implode/0":        implode,
+	"tostring/0":       toString,
+	"tonumber/0":       toNumber,
+	"test/1":           test,
+	"test/2":           test,
+	"match/1":          match,
+	"match/2":          match,
+	"capture/1":        capture,
+	"capture/2":        capture,
+	"scan/1":           scan,
+	"scan/2":           scan,
+	"split/2":          splitRegexp,
+	"splits/1":         splits,
+	"splits/2":         splits,
+	"sub/2":            sub,
+	"sub/3":            sub,
+	"gsub/2":           gsub,
+	"gsub/3":           gsub,
+}
+
+// signature returns the signature of the function with the given name and
+// number of arguments used as the key of the function registry.
+func signature(name string, arity int) string {
+	return name + "/" + strconv.Itoa(arity)
+}
+
+// typeError returns the error reporting that the builtin function with the
+// given name cannot be applied to the data.
+func typeError(data any, name string) error {
+	return &Error{data: data, filter: name, err: ErrTOMLDataType}
+}
+
+// stopped is returned by the emitter to stop the stream once the generator
+// has all the outputs it needs. Every generator call makes a stopped value of
+// its own, so that nested generators can tell their own stops apart.
+type stopped struct {
+	_ byte // NOTE: Pointers to distinct zero-size values may be equal.
+}
+
+func (*stopped) Error() string {
+	return "stream stopped"
+}
+
+// take runs the stream s against the data and passes at most n of its first
+// outputs to emit. The stream stops as soon as it produces n outputs.
+func take(s stream, data any, n int, emit emitter) error {
+	if n <= 0 {
+		return nil
+	}
+	stop := &stopped{}
+	err := s(data, func(v any) error {
+		if err := emit(v); err != nil {
+			return err
+		}
+		n--
+		if n == 0 {
+			return stop
+		}
+		return nil
+	})
+	if errors.Is(err, stop) {
+		return nil
+	}
+	return err
+}
+
+// elements returns the elements of an array or the values of a table in the
+// order of its keys. It reports false for data of any other kind.
+func elements(data any) ([]any, bool) {
+	switch v := data.(type) {
+	case []any:
+		return v, true
+	case map[string]any:
+		result := make([]any, 0, len(v))
+		for _, k := range sortedKeys(v) {
+			result = append(result, v[k])
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// integer converts the number v into an integer. It reports false for values
+// other than integers and floats without the fractional part.
+func integer(v any) (int, bool) {
+	switch n := v.(type) {
+	case int64:
+		return int(n), true
+	case float64:
+		if n == math.Trunc(n) && math.Abs(n) < math.MaxInt32 {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
+
+// integers runs the stream s against the data and passes every output as an
+// integer to fn. The name of the calling builtin is used to report outputs
+// that are not integers.
+func integers(s stream, data any, name string, fn func(n int) error) error {
+	return s(data, func(v any) error {
+		n, ok := integer(v)
+		if !ok {
+			return typeError(v, name)
+		}
+		return fn(n)
+	})
+}
+
+func not(data any, _ []stream, emit emitter) error {
+	return emit(!truthy(data))
+}
+
+// selection passes the data through for every output of the condition that
+// is true in a boolean context.
+func selection(data any, args []stream, emit emitter) error {
+	return args[0](data, func(c any) error {
+		if truthy(c) {
+			return emit(data)
+		}
+		return nil
+	})
+}
+
+// length returns the number of characters of a string, the number of elements
+// of an array or a table, and the absolute value of a number.
+func length(data any, _ []stream, emit emitter) error {
+	switch v := data.(type) {
+	case string:
+		return emit(int64(utf8.RuneCountInString(v)))
+	case []any:
+		return emit(int64(len(v)))
+	case map[string]any:
+		return emit(int64(len(v)))
+	case int64:
+		if v == math.MinInt64 {
+			return &Error{data: data, filter: "length", err: ErrIntegerOverflow}
+		}
+		return emit(max(v, -v))
+	case float64:
+		return emit(math.Abs(v))
+	}
+	return typeError(data, "length")
+}
+
+// keys returns the sorted keys of a table or the indexes of an array. Decoded
+// tables do not retain the order of keys from the TOML input, so keys come
+// sorted even when the order is not requested.
+func keys(data any, _ []stream, emit emitter) error {
+	switch v := data.(type) {
+	case map[string]any:
+		result := make([]any, 0, len(v))
+		for _, k := range sortedKeys(v) {
+			result = append(result, k)
+		}
+		return emit(result)
+	case []any:
+		result := make([]any, 0, len(v))
+		for n := range v {
+			result = append(result, int64(n))
+		}
+		return emit(result)
+	}
+	return typeError(data, "keys")
+}
+
+// values returns the values of a table in the order of its sorted keys or the
+// elements of an array.
+func values(data any, _ []stream, emit emitter) error {
+	vs, ok := elements(data)
+	if !ok {
+		return typeError(data, "values")
+	}
+	return emit(vs)
+}
+
+// has reports if a table has the key or if an array has the index for every
+// output of the argument.
+func has(data any, args []stream, emit emitter) error {
+	return args[0](data, func(k any) error {
+		ok, err := hasKey(data, k, "has")
+		if err != nil {
+			return err
+		}
+		return emit(ok)
+	})
+}
+
+// in reports if the data is a key of a table or an index of an array for
+// every output of the argument.
+func in(data any, args []stream, emit emitter) error {
+	return args[0](data, func(v any) error {
+		ok, err := hasKey(v, data, "in")
+		if err != nil {
+			return err
+		}
+		return emit(ok)
+	})
+}
+
+// hasKey reports if the table v has the string key k or if the array v has
+// the integer index k. The name of the calling builtin is used to report keys
+// of the wrong type.
+func hasKey(v, k any, name string) (bool, error) {
+	switch c := v.(type) {
+	case map[string]any:
+		if key, ok := k.(string); ok {
+			_, ok = c[key]
+			return ok, nil
+		}
+	case []any:
+		if idx, ok := k.(int64); ok {
+			return idx >= 0 && idx < int64(len(c)), nil
+		}
+	}
+	return false, &OperandError{v, k, name, ErrTOMLDataType}
+}
+
+func typeOf(data any, _ []stream, emit emitter) error {
+	return emit(typeName(data))
+}
+
+// mapping runs the argument against every element of an array or every value
+// of a table in the order of its keys, and it collects all outputs into a
+// single array.
+func mapping(data any, args []stream, emit emitter) error {
+	elems, ok := elements(data)
+	if !ok {
+		return typeError(data, "map")
+	}
+	result, err := collect(args[0], elems...)
+	if err != nil {
+		return err
+	}
+	return emit(result)
+}
+
+// mapValues replaces every element of an array or every value of a table with
+// the first output of the argument run against it. Elements for which the
+// argument produces no output are left out.
+func mapValues(data any, args []stream, emit emitter) error {
+	switch v := data.(type) {
+	case []any:
+		result := make([]any, 0, len(v))
+		for _, e := range v {
+			err := take(args[0], e, 1, func(x any) error {
+				result = append(result, x)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return emit(result)
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for _, k := range sortedKeys(v) {
+			err := take(args[0], v[k], 1, func(x any) error {
+				result[k] = x
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return emit(result)
+	}
+	return typeError(data, "map_values")
+}
+
+// walk rebuilds arrays and tables bottom-up by walking their elements first,
+// and then it runs the argument against the rebuilt value.
+func walk(data any, args []stream, emit emitter) error {
+	var rebuild builtin
+	switch data.(type) {
+	case []any:
+		rebuild = mapping
+	case map[string]any:
+		rebuild = mapValues
+	}
+	if rebuild != nil {
+		walker := func(d any, emit emitter) error {
+			return walk(d, args, emit)
+		}
+		err := rebuild(data, []stream{walker}, func(v any) error {
+			data = v
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return args[0](data, emit)
+}
+
+// keyed pairs an array element with its sorting key.
+type keyed struct {
+	key, value any
+}
+
+// sortedBy pairs every element of the array with the array of outputs of the
+// stream s run against it, and it stable-sorts the pairs by their keys. The
+// name of the calling builtin is used to report non-array data.
+func sortedBy(data any, name string, s stream) ([]keyed, error) {
+	arr, ok := data.([]any)
+	if !ok {
+		return nil, typeError(data, name)
+	}
+	result := make([]keyed, 0, len(arr))
+	for _, e := range arr {
+		ks, err := collect(s, e)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, keyed{ks, e})
+	}
+	slices.SortStableFunc(result, func(a, b keyed) int {
+		return order(a.key, b.key)
+	})
+	return result, nil
+}
+
+// grouped splits sorted pairs into groups of consecutive pairs with equal keys.
+func grouped(ks []keyed) [][]keyed {
+	var result [][]keyed
+	for n, k := range ks {
+		if n == 0 || order(ks[n-1].key, k.key) != 0 {
+			result = append(result, nil)
+		}
+		result[len(result)-1] = append(result[len(result)-1], k)
+	}
+	return result
+}
+
+// sorting sorts the array in the total ordering of TOML values.
+func sorting(data any, _ []stream, emit emitter) error {
+	return sortByKey(data, "sort", identity, emit)
+}
+
+// sortBy sorts the array by outputs of the argument run against its elements.
+func sortBy(data any, args []stream, emit emitter) error {
+	return sortByKey(data, "sort_by", args[0], emit)
+}
+
+func sortByKey(data any, name string, s stream, emit emitter) error {
+	ks, err := sortedBy(data, name, s)
+	if err != nil {
+		return err
+	}
+	result := make([]any, 0, len(ks))
+	for _, k := range ks {
+		result = append(result, k.value)
+	}
+	return emit(result)
+}
+
+// groupBy groups the elements of the array with equal outputs of the argument
+// into arrays sorted by these outputs.
+func groupBy(data any, args []stream, emit emitter) error {
+	ks, err := sortedBy(data, "group_by", args[0])
+	if err != nil {
+		return err
+	}
+	result := []any{}
+	for _, g := range grouped(ks) {
+		group := make([]any, 0, len(g))
+		for _, k := range g {
+			group = append(group, k.value)
+		}
+		result = append(result, group)
+	}
+	return emit(result)
+}
+
+// unique sorts the array and leaves out duplicate elements.
+func unique(data any, _ []stream, emit emitter) error {
+	return uniqueByKey(data, "unique", identity, emit)
+}
+
+// uniqueBy keeps the first element of the array for each distinct output of
+// the argument, and it sorts the elements by these outputs.
+func uniqueBy(data any, args []stream, emit emitter) error {
+	return uniqueByKey(data, "unique_by", args[0], emit)
+}
+
+func uniqueByKey(data any, name string, s stream, emit emitter) error {
+	ks, err := sortedBy(data, name, s)
+	if err != nil {
+		return err
+	}
+	result := []any{}
+	for _, g := range grouped(ks) {
+		result = append(result, g[0].value)
+	}
+	return emit(result)
+}
+
+// minBy returns the first element of the array with the least output of the
+// argument. There is no output for the empty array.
+func minBy(data any, args []stream, emit emitter) error {
+	return minByKey(data, "min_by", args[0], emit)
+}
+
+func minByKey(data any, name string, s stream, emit emitter) error {
+	ks, err := sortedBy(data, name, s)
+	if err != nil || len(ks) == 0 {
+		return err
+	}
+	return emit(ks[0].value)
+}
+
+// maxBy returns the last element of the array with the greatest output of the
+// argument. There is no output for the empty array.
+func maxBy(data any, args []stream, emit emitter) error {
+	return maxByKey(data, "max_by", args[0], emit)
+}
+
+func maxByKey(data any, name string, s stream, emit emitter) error {
+	ks, err := sortedBy(data, name, s)
+	if err != nil || len(ks) == 0 {
+		return err
+	}
+	return emit(ks[len(ks)-1].value)
+}
+
+// sum adds up the elements of an array or the values of a table with the +
+// operator. There is no output for the empty array or table.
+func sum(data any, _ []stream, emit emitter) error {
+	elems, ok := elements(data)
+	if !ok {
+		return typeError(data, "add")
+	}
+	if len(elems) == 0 {
+		return nil
+	}
+	result := elems[0]
+	for _, e := range elems[1:] {
+		v, err := add(result, e)
+		if err != nil {
+			return &OperandError{result, e, "add", err}
+		}
+		result = v
+	}
+	return emit(result)
+}
+
+// minimum returns the least element of the array in the total ordering of TOML
+// values. There is no output for the empty array.
+func minimum(data any, _ []stream, emit emitter) error {
+	return minByKey(data, "min", identity, emit)
+}
+
+// maximum returns the greatest element of the array in the total ordering of
+// TOML values. There is no output for the empty array.
+func maximum(data any, _ []stream, emit emitter) error {
+	return maxByKey(data, "max", identity, emit)
+}
+
+// iterate emits the elements of an array or the values of a table.
+func iterate(data any, emit emitter) error {
+	elems, ok := elements(data)
+	if !ok {
+		return typeError(data, "iterator")
+	}
+	for _, e := range elems {
+		if err := emit(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// search runs the condition against every output of the generator until the
+// condition produces an output that is want in a boolean context. It reports
+// if such an output was found, and it stops the generator as soon as it is.
+func search(data any, generator, condition stream, want bool) (bool, error) {
+	stop := &stopped{}
+	err := generator(data, func(g any) error {
+		return condition(g, func(c any) error {
+			if truthy(c) == want {
+				return stop
+			}
+			return nil
+		})
+	})
+	if errors.Is(err, stop) {
+		return true, nil
+	}
+	return false, err
+}
+
+// quantifier returns the generator and the condition of any and all. Without
+// the generator they run against elements of the input array or table, and
+// without the condition the elements themselves are checked.
+func quantifier(args []stream) (stream, stream) {
+	switch len(args) {
+	case 0:
+		return iterate, identity
+	case 1:
+		return iterate, args[0]
+	default:
+		return args[0], args[1]
+	}
+}
+
+// anyOf reports if any output of the generator satisfies the condition.
+func anyOf(data any, args []stream, emit emitter) error {
+	generator, condition := quantifier(args)
+	found, err := search(data, generator, condition, true)
+	if err != nil {
+		return err
+	}
+	return emit(found)
+}
+
+// allOf reports if all outputs of the generator satisfy the condition.
+func allOf(data any, args []stream, emit emitter) error {
+	generator, condition := quantifier(args)
+	found, err := search(data, generator, condition, false)
+	if err != nil {
+		return err
+	}
+	return emit(!found)
+}
+
+// flatten replaces nested arrays of the array with their elements down to the
+// depth given by the argument. Without the argument it flattens all of them.
+func flatten(data any, args []stream, emit emitter) error {
+	arr, ok := data.([]any)
+	if !ok {
+		return typeError(data, "flatten")
+	}
+	if len(args) == 0 {
+		return emit(flattened([]any{}, arr, -1))
+	}
+	return integers(args[0], data, "flatten", func(depth int) error {
+		if depth < 0 {
+			return &Error{data: int64(depth), filter: "flatten", err: ErrArgumentValue}
+		}
+		return emit(flattened([]any{}, arr, depth))
+	})
+}
+
+// flattened appends elements of the array to the result replacing nested
+// arrays with their elements down to the depth. Negative depth has no limit.
+func flattened(result, arr []any, depth int) []any {
+	for _, e := range arr {
+		if nested, ok := e.([]any); ok && depth != 0 {
+			result = flattened(result, nested, depth-1)
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// span generates numbers from the lower bound up to but excluding the upper
+// bound by the step. The lower bound defaults to zero and the step to one.
+// Numbers are integers if all arguments are integers and floats otherwise.
+func span(data any, args []stream, emit emitter) error {
+	bounds := []stream{
+		func(_ any, emit emitter) error { return emit(int64(0)) },
+		nil,
+		func(_ any, emit emitter) error { return emit(int64(1)) },
+	}
+	switch len(args) {
+	case 1:
+		bounds[1] = args[0]
+	default:
+		copy(bounds, args)
+	}
+	return bounds[0](data, func(from any) error {
+		return bounds[1](data, func(upto any) error {
+			return bounds[2](data, func(by any) error {
+				return generate(from, upto, by, emit)
+			})
+		})
+	})
+}
+
+// generate emits numbers from the number from up to the number upto by the
+// number by. There is no output for the zero step.
+func generate(from, upto, by any, emit emitter) error {
+	for _, v := range []any{from, upto, by} {
+		if _, ok := v.(int64); ok {
+			continue
+		}
+		if _, ok := v.(float64); !ok {
+			return typeError(v, "range")
+		}
+	}
+	a, aok := from.(int64)
+	b, bok := upto.(int64)
+	c, cok := by.(int64)
+	if aok && bok && cok {
+		for v := a; c > 0 && v < b || c < 0 && v > b; {
+			if err := emit(v); err != nil {
+				return err
+			}
+			next, err := addInts(v, c)
+			if err != nil {
+				return nil
+			}
+			v = next.(int64)
+		}
+		return nil
+	}
+	x, y, z := float(from), float(upto), float(by)
+	for v := x; z > 0 && v < y || z < 0 && v > y; v += z {
+		if err := emit(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// float converts the integer or the float v into a float.
+func float(v any) float64 {
+	if n, ok := v.(int64); ok {
+		return float64(n)
+	}
+	return v.(float64)
+}
+
+// first returns the first element of the array or the first output of the
+// argument. The argument stops as soon as it produces its first output.
+func first(data any, args []stream, emit emitter) error {
+	if len(args) == 1 {
+		return take(args[0], data, 1, emit)
+	}
+	return nth(data, []stream{literal(int64(0))}, emit)
+}
+
+// last returns the last element of the array or the last output of the
+// argument.
+func last(data any, args []stream, emit emitter) error {
+	if len(args) == 0 {
+		return nth(data, []stream{literal(int64(-1))}, emit)
+	}
+	var result any
+	found := false
+	err := args[0](data, func(v any) error {
+		result, found = v, true
+		return nil
+	})
+	if err != nil || !found {
+		return err
+	}
+	return emit(result)
+}
+
+// limit passes through at most the number of first outputs of the second
+// argument given by the first argument. The second argument stops as soon as
+// it produces enough outputs.
+func limit(data any, args []stream, emit emitter) error {
+	return integers(args[0], data, "limit", func(n int) error {
+		return take(args[1], data, n, emit)
+	})
+}
+
+// nth returns the element of the array at the index given by the argument or
+// the output of the second argument at this index. Negative indexes count
+// back from the end of the array, but they are not allowed for outputs.
+func nth(data any, args []stream, emit emitter) error {
+	if len(args) == 1 {
+		arr, ok := data.([]any)
+		if !ok {
+			return typeError(data, "nth")
+		}
+		return integers(args[0], data, "nth", func(n int) error {
+			if n < 0 {
+				n += len(arr)
+			}
+			if n < 0 || n >= len(arr) {
+				return nil
+			}
+			return emit(arr[n])
+		})
+	}
+	return integers(args[0], data, "nth", func(n int) error {
+		if n < 0 {
+			return &Error{data: int64(n), filter: "nth", err: ErrArgumentValue}
+		}
+		var result any
+		found := false
+		err := take(args[1], data, n+1, func(v any) error {
+			result, found = v, n == 0
+			n--
+			return nil
+		})
+		if err != nil || !found {
+			return err
+		}
+		return emit(result)
+	})
+}
+
+// literal returns the stream emitting the value v regardless of its input.
+func literal(v any) stream {
+	return func(_ any, emit emitter) error {
+		return emit(v)
+	}
+}
@@ -0,0 +1,460 @@
+package interpreter
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Test builtin functions called from queries run against TOML data.
+func TestBuiltins(t *testing.T) {
+	data := map[string]any{
+		"name":    "zażółć",
+		"ports":   []any{int64(80), int64(443)},
+		"offset":  int64(-3),
+		"ratio":   -0.5,
+		"servers": map[string]any{"beta": "10.0.0.2", "alpha": "10.0.0.1"},
+		"enabled": true,
+		"since":   time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+		"date":    toml.LocalDate{Year: 1979, Month: 5, Day: 27},
+		"time":    toml.LocalTime{Hour: 7, Minute: 32},
+		"moment":  toml.LocalDateTime{},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`.name | length`, []any{int64(6)}},
+		{`.ports | length`, []any{int64(2)}},
+		{`.servers | length`, []any{int64(2)}},
+		{`.offset | length`, []any{int64(3)}},
+		{`.ratio | length`, []any{0.5}},
+		{`.servers | keys`, []any{[]any{"alpha", "beta"}}},
+		{`.servers | keys_unsorted`, []any{[]any{"alpha", "beta"}}},
+		{`.ports | keys`, []any{[]any{int64(0), int64(1)}}},
+		{`.servers | values`, []any{[]any{"10.0.0.1", "10.0.0.2"}}},
+		{`.ports | values`, []any{[]any{int64(80), int64(443)}}},
+		{`.servers | has("alpha")`, []any{true}},
+		{`.servers | has("gamma", "beta")`, []any{false, true}},
+		{`.ports | has(1)`, []any{true}},
+		{`.ports | has(2)`, []any{false}},
+		{`.ports | has(-1)`, []any{false}},
+		{`"alpha" | in({alpha: 1})`, []any{true}},
+		{`.ports[] | in([1, 2, 3])`, []any{false, false}},
+		{`.enabled | not`, []any{false}},
+		{`.ports[] | select(. > 100)`, []any{int64(443)}},
+		{`.enabled | type`, []any{"boolean"}},
+		{`.offset | type`, []any{"integer"}},
+		{`.ratio | type`, []any{"float"}},
+		{`.name | type`, []any{"string"}},
+		{`.since | type`, []any{"offset-datetime"}},
+		{`.moment | type`, []any{"local-datetime"}},
+		{`.date | type`, []any{"local-date"}},
+		{`.time | type`, []any{"local-time"}},
+		{`.ports | type`, []any{"array"}},
+		{`.servers | type`, []any{"table"}},
+		{`servers | keys`, []any{[]any{"alpha", "beta"}}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Check if bare names of builtin functions opening the query call the
+// functions while other bare names and dotted names select keys.
+func TestBuiltinsShadowKeys(t *testing.T) {
+	data := map[string]any{
+		"type":    "web",
+		"keys":    int64(1),
+		"servers": map[string]any{"alpha": "10.0.0.1"},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`type`, []any{"table"}},
+		{`.type`, []any{"web"}},
+		{`keys`, []any{[]any{"keys", "servers", "type"}}},
+		{`.["keys"]`, []any{int64(1)}},
+		{`servers`, []any{map[string]any{"alpha": "10.0.0.1"}}},
+		{`lenght`, []any{}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if builtin functions report errors for data they cannot handle.
+func TestBuiltinsError(t *testing.T) {
+	data := map[string]any{
+		"enabled": true,
+		"min":     int64(math.MinInt64),
+		"servers": map[string]any{"alpha": "10.0.0.1"},
+	}
+	cases := []struct {
+		query string
+		want  error
+	}{
+		{`.enabled | length`, ErrTOMLDataType},
+		{`.min | length`, ErrIntegerOverflow},
+		{`.enabled | keys`, ErrTOMLDataType},
+		{`.enabled | values`, ErrTOMLDataType},
+		{`.servers | has(0)`, ErrTOMLDataType},
+		{`0 | in(.servers)`, ErrTOMLDataType},
+		{`length(1)`, ErrFunctionUndefined},
+		{`undefined(.servers)`, ErrFunctionUndefined},
+		{`.servers | map(lenght)`, ErrFunctionUndefined},
+		{`. | servers`, ErrFunctionUndefined},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			_, err := run(t, c.query, data)
+			if !errors.Is(err, c.want) {
+				t.Errorf("have: %v; want: %v", err, c.want)
+			}
+		})
+	}
+}
+
+// Verify if errors of builtins sharing helpers name the builtin in the query.
+func TestBuiltinsErrorName(t *testing.T) {
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: `0 | in({a: 1})`,
+			want: "Interpreter error: cannot apply ( in ) to " +
+				"[ map[string]interface {} ] ( map[a:1] ) and " +
+				"[ int64 ] ( 0 ): wrong type error",
+		},
+		{
+			query: `true | min`,
+			want: "Interpreter error: cannot query [ bool ] ( true ) " +
+				"with ( min )",
+		},
+		{
+			query: `true | max`,
+			want: "Interpreter error: cannot query [ bool ] ( true ) " +
+				"with ( max )",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			_, err := run(t, c.query, map[string]any{})
+			if err == nil {
+				t.Fatal("expected the query to fail")
+			}
+			if have := err.Error(); have != c.want {
+				t.Errorf("have: %s; want: %s", have, c.want)
+			}
+		})
+	}
+}
+
+// Check if transformation builtins rebuild arrays and tables as expected.
+func TestBuiltinsTransform(t *testing.T) {
+	data := map[string]any{
+		"ports": []any{int64(80), int64(443)},
+		"limits": map[string]any{
+			"max_conns": int64(100),
+			"timeout":   int64(30),
+		},
+		"config": map[string]any{
+			"name": "web",
+			"tags": []any{"a", int64(1), map[string]any{"t": "b"}},
+		},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`.ports | map(. + 1)`, []any{[]any{int64(81), int64(444)}}},
+		{`.ports | map(., .)`, []any{[]any{int64(80), int64(80), int64(443), int64(443)}}},
+		{`.ports | map(select(. > 100))`, []any{[]any{int64(443)}}},
+		{`.limits | map(. * 2)`, []any{[]any{int64(200), int64(60)}}},
+		{
+			`.limits | map_values(. * 2)`,
+			[]any{map[string]any{"max_conns": int64(200), "timeout": int64(60)}},
+		},
+		{
+			`.limits | map_values(select(. > 50))`,
+			[]any{map[string]any{"max_conns": int64(100)}},
+		},
+		{`.ports | map_values(., 0)`, []any{[]any{int64(80), int64(443)}}},
+		{
+			`.config | walk((select(type == "string") | . + "!"), select(type != "string"))`,
+			[]any{
+				map[string]any{
+					"name": "web!",
+					"tags": []any{"a!", int64(1), map[string]any{"t": "b!"}},
+				},
+			},
+		},
+		{`.ports | walk(length)`, []any{int64(2)}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if transformation builtins report errors for data that is neither an
+// array nor a table.
+func TestBuiltinsTransformError(t *testing.T) {
+	data := map[string]any{"name": "web", "ports": []any{"80"}}
+	cases := []string{
+		`.name | map(.)`,
+		`.name | map_values(.)`,
+		`.ports | map(. + 1)`,
+		`.ports | walk(. + 1)`,
+	}
+	for _, c := range cases {
+		t.Run(c, func(t *testing.T) {
+			_, err := run(t, c, data)
+			if !errors.Is(err, ErrTOMLDataType) {
+				t.Errorf("have: %v; want: %v", err, ErrTOMLDataType)
+			}
+		})
+	}
+}
+
+// Test sorting and grouping builtins on arrays of TOML values.
+func TestBuiltinsSort(t *testing.T) {
+	pkg := func(name, version string) map[string]any {
+		return map[string]any{"name": name, "version": version}
+	}
+	data := map[string]any{
+		"package": []any{
+			pkg("serde", "1.0.1"),
+			pkg("anyhow", "1.0.75"),
+			pkg("serde", "1.0.0"),
+			pkg("log", "0.4.5"),
+		},
+		"mixed": []any{
+			map[string]any{},
+			"b",
+			int64(2),
+			[]any{},
+			true,
+			1.5,
+			"a",
+		},
+		"empty": []any{},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{
+			`.mixed | sort`,
+			[]any{[]any{true, 1.5, int64(2), "a", "b", []any{}, map[string]any{}}},
+		},
+		{
+			`.package | sort_by(.name) | map(.version)`,
+			[]any{[]any{"1.0.75", "0.4.5", "1.0.1", "1.0.0"}},
+		},
+		{
+			`.package | sort_by(.name, .version) | map(.version)`,
+			[]any{[]any{"1.0.75", "0.4.5", "1.0.0", "1.0.1"}},
+		},
+		{
+			`.package | group_by(.name) | map(length)`,
+			[]any{[]any{int64(1), int64(1), int64(2)}},
+		},
+		{
+			`.package | map(.name) | unique`,
+			[]any{[]any{"anyhow", "log", "serde"}},
+		},
+		{
+			`.package | unique_by(.name) | map(.version)`,
+			[]any{[]any{"1.0.75", "0.4.5", "1.0.1"}},
+		},
+		{`.package | min_by(.version) | .name`, []any{"log"}},
+		{`.package | max_by(.version) | .name`, []any{"anyhow"}},
+		{`.package | max_by(.name) | .version`, []any{"1.0.0"}},
+		{`.empty | sort`, []any{[]any{}}},
+		{`.empty | group_by(.)`, []any{[]any{}}},
+		{`.empty | min_by(.)`, []any{}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Test aggregation builtins reducing arrays and tables to a single value.
+func TestBuiltinsAggregate(t *testing.T) {
+	data := map[string]any{
+		"ports":   []any{int64(80), int64(443), int64(8080)},
+		"weights": []any{int64(1), 0.5},
+		"names":   []any{"web", "-", "01"},
+		"nested":  []any{int64(1), []any{int64(2), []any{int64(3), []any{}}}},
+		"limits":  map[string]any{"max_conns": int64(100), "timeout": int64(30)},
+		"flags":   []any{false, true},
+		"empty":   []any{},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`.ports | add`, []any{int64(8603)}},
+		{`.weights | add`, []any{1.5}},
+		{`.names | add`, []any{"web-01"}},
+		{`.limits | add`, []any{int64(130)}},
+		{`[.ports, .weights] | add`, []any{[]any{int64(80), int64(443), int64(8080), int64(1), 0.5}}},
+		{`.empty | add`, []any{}},
+		{`.ports | min`, []any{int64(80)}},
+		{`.ports | max`, []any{int64(8080)}},
+		{`.names | min`, []any{"-"}},
+		{`.empty | max`, []any{}},
+		{`.flags | any`, []any{true}},
+		{`.flags | all`, []any{false}},
+		{`.empty | any`, []any{false}},
+		{`.empty | all`, []any{true}},
+		{`.ports | any(. > 1000)`, []any{true}},
+		{`.ports | all(. > 1000)`, []any{false}},
+		{`.limits | all(. > 10)`, []any{true}},
+		{`any(.ports[]; . == 443)`, []any{true}},
+		{`all(.ports[]; . < 443)`, []any{false}},
+		{`any(true, 1 / 0; .)`, []any{true}},
+		{`all(false, 1 / 0; .)`, []any{false}},
+		{`.nested | flatten`, []any{[]any{int64(1), int64(2), int64(3)}}},
+		{`.nested | flatten(1)`, []any{[]any{int64(1), int64(2), []any{int64(3), []any{}}}}},
+		{`.nested | flatten(0)`, []any{[]any{int64(1), []any{int64(2), []any{int64(3), []any{}}}}}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Test generator builtins and check if the ones taking a number of outputs
+// stop their generators before they produce anything more.
+func TestBuiltinsGenerate(t *testing.T) {
+	data := map[string]any{
+		"ports": []any{int64(80), int64(443), int64(8080)},
+		"empty": []any{},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`range(3)`, []any{int64(0), int64(1), int64(2)}},
+		{`range(2; 4)`, []any{int64(2), int64(3)}},
+		{`range(0; 10; 4)`, []any{int64(0), int64(4), int64(8)}},
+		{`range(3; 0; -1)`, []any{int64(3), int64(2), int64(1)}},
+		{`range(0; 1; 0.5)`, []any{0.0, 0.5}},
+		{`range(0; 1; 0)`, []any{}},
+		{`range(-1)`, []any{}},
+		{`range(0, 1; 2)`, []any{int64(0), int64(1), int64(1)}},
+		{`[range(9223372036854775806; 9223372036854775807; 2)]`, []any{[]any{int64(9223372036854775806)}}},
+		{`.ports | first`, []any{int64(80)}},
+		{`.ports | last`, []any{int64(8080)}},
+		{`.empty | first`, []any{}},
+		{`first(.ports[])`, []any{int64(80)}},
+		{`last(.ports[])`, []any{int64(8080)}},
+		{`first(.empty[])`, []any{}},
+		{`first(1, 1 / 0)`, []any{int64(1)}},
+		{`limit(2; .ports[])`, []any{int64(80), int64(443)}},
+		{`limit(0; .ports[])`, []any{}},
+		{`limit(-1; .ports[])`, []any{}},
+		{`limit(2; 1, 2, 1 / 0)`, []any{int64(1), int64(2)}},
+		{`limit(3; range(0; 9223372036854775807))`, []any{int64(0), int64(1), int64(2)}},
+		{`limit(1; limit(2; 1, 2), 3)`, []any{int64(1)}},
+		{`[limit(1; 1, 2), limit(1; 3, 4)]`, []any{[]any{int64(1), int64(3)}}},
+		{`.ports | nth(1)`, []any{int64(443)}},
+		{`.ports | nth(-1)`, []any{int64(8080)}},
+		{`.ports | nth(3)`, []any{}},
+		{`nth(1; .ports[])`, []any{int64(443)}},
+		{`nth(1; 1, 2, 1 / 0)`, []any{int64(2)}},
+		{`nth(5; .ports[])`, []any{}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if aggregation and generator builtins report errors for data and
+// arguments they cannot handle.
+func TestBuiltinsGenerateError(t *testing.T) {
+	data := map[string]any{
+		"name":  "web",
+		"mixed": []any{int64(1), "a"},
+		"ports": []any{int64(80)},
+	}
+	cases := []struct {
+		query string
+		want  error
+	}{
+		{`.name | add`, ErrTOMLDataType},
+		{`.mixed | add`, ErrTOMLDataType},
+		{`.name | any`, ErrTOMLDataType},
+		{`.name | flatten`, ErrTOMLDataType},
+		{`.ports | flatten(-1)`, ErrArgumentValue},
+		{`.ports | flatten("1")`, ErrTOMLDataType},
+		{`range("3")`, ErrTOMLDataType},
+		{`limit(1.5; .ports[])`, ErrTOMLDataType},
+		{`.name | nth(0)`, ErrTOMLDataType},
+		{`nth(-1; .ports[])`, ErrArgumentValue},
+		{`first(1 / 0)`, ErrDivisionByZero},
+		{`limit(2; 1, 1 / 0)`, ErrDivisionByZero},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			_, err := run(t, c.query, data)
+			if !errors.Is(err, c.want) {
+				t.Errorf("have: %v; want: %v", err, c.want)
+			}
+		})
+	}
+}
@@ -8,6 +8,35 @@ import (
 var (
 	// ErrTOMLDataType indicates unexpected data type passed to the function.
 	ErrTOMLDataType = errors.New("wrong type error")
+
+	// ErrIntegerOverflow indicates an integer result that does not fit in
+	// 64 bits.
+	ErrIntegerOverflow = errors.New("integer overflow")
+
+	// ErrFunctionUndefined indicates the call of a function that is not
+	// defined with the given number of arguments.
+	ErrFunctionUndefined = errors.New("undefined function")
+
+	// ErrVariableUndefined indicates the reference to a variable that is not
+	// bound in its scope.
+	ErrVariableUndefined = errors.New("undefined variable")
+
+	// ErrFormatUndefined indicates the format string that is not defined.
+	ErrFormatUndefined = errors.New("undefined format")
+
+	// ErrDivisionByZero indicates an integer division by zero.
+	ErrDivisionByZero = errors.New("division by zero")
+
+	// ErrArgumentValue indicates a function argument of the right type with
+	// the value that the function does not accept.
+	ErrArgumentValue = errors.New("invalid argument value")
+
+	// ErrConversion indicates a value that cannot be converted to the value
+	// of another data type.
+	ErrConversion = errors.New("conversion error")
+
+	// ErrRegexp indicates a regular expression that cannot be compiled.
+	ErrRegexp = errors.New("invalid regular expression")
 )
 
 // Error wraps an interpreter error to show how a given data type and value
@@ -24,8 +53,17 @@ func (e *Error) Is(target error) bool {
 }
 
 // Error reports the Interpreter error with the data type and value followed
-// by the name of the data filter that was to be applied to this data.
+// by the name of the data filter that was to be applied to this data. Errors
+// that do not depend on the queried data, such as undefined names, leave the
+// data out and report their cause instead.
 func (e *Error) Error() string {
+	if e.data == nil {
+		return fmt.Sprintf(
+			"Interpreter error: cannot evaluate ( %s ): %v",
+			e.filter,
+			e.err,
+		)
+	}
 	return fmt.Sprintf(
 		"Interpreter error: cannot query [ %T ] ( %v ) with ( %s )",
 		e.data,
@@ -33,3 +71,31 @@ func (e *Error) Error() string {
 		e.filter,
 	)
 }
+
+// OperandError wraps an interpreter error to show how a binary operator cannot
+// be applied to the given pair of operands.
+type OperandError struct {
+	left, right any
+	operator    string
+	err         error
+}
+
+// Is allows to check if OperandError.err matches the target error.
+func (e *OperandError) Is(target error) bool {
+	return e.err == target
+}
+
+// Error reports the Interpreter error with data types and values of both
+// operands preceded by the name of the operator that was to be applied and
+// followed by the cause of the error.
+func (e *OperandError) Error() string {
+	return fmt.Sprintf(
+		"Interpreter error: cannot apply ( %s ) to [ %T ] ( %v ) and [ %T ] ( %v ): %v",
+		e.operator,
+		e.left,
+		e.left,
+		e.right,
+		e.right,
+		e.err,
+	)
+}
@@ -24,6 +24,14 @@ func TestErrorError(t *testing.T) {
 				"( map[x:y] ) " +
 				"with ( string \"persons\" )",
 		},
+		{
+			name:   "undefined",
+			data:   nil,
+			filter: "$x",
+			err:    ErrVariableUndefined,
+			want: "Interpreter error: cannot evaluate ( $x ): " +
+				"undefined variable",
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -55,3 +63,56 @@ func TestErrorIs(t *testing.T) {
 		})
 	}
 }
+
+// Test if interpreter OperandError has the expected string representation.
+func TestOperandErrorError(t *testing.T) {
+	cases := []struct {
+		name        string
+		left, right any
+		operator    string
+		err         error
+		want        string
+	}{
+		{
+			name:     "comparison",
+			left:     int64(1),
+			right:    "a",
+			operator: "binary <",
+			err:      ErrTOMLDataType,
+			want: "Interpreter error: cannot apply ( binary < ) to " +
+				"[ int64 ] ( 1 ) and [ string ] ( a ): wrong type error",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := &OperandError{
+				left:     c.left,
+				right:    c.right,
+				operator: c.operator,
+				err:      c.err,
+			}
+			if have := err.Error(); have != c.want {
+				t.Errorf("have: %s; want: %s", have, c.want)
+			}
+		})
+	}
+}
+
+// Test if the OperandError matches the embedded error with errors.Is().
+func TestOperandErrorIs(t *testing.T) {
+	cases := []struct {
+		want error
+	}{
+		{want: ErrTOMLDataType},
+		{want: ErrIntegerOverflow},
+		{want: ErrDivisionByZero},
+	}
+	for _, c := range cases {
+		t.Run(c.want.Error(), func(t *testing.T) {
+			err := &OperandError{err: c.want}
+			if !errors.Is(err, c.want) {
+				t.Errorf("the errors should match: %s : %s", err, c.want)
+			}
+		})
+	}
+}
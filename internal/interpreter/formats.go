@@ -0,0 +1,155 @@
+package interpreter
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// format renders a single value as a string in the given encoding.
+type format func(v any) (string, error)
+
+// formats maps names of format strings onto format functions.
+var formats = map[string]format{
+	"text":    text,
+	"json":    formatJSON,
+	"csv":     formatCSV,
+	"tsv":     formatTSV,
+	"sh":      formatSh,
+	"base64":  formatBase64,
+	"base64d": formatBase64Decode,
+	"uri":     formatURI,
+	"html":    formatHTML,
+}
+
+// formatJSON renders the value as JSON text.
+func formatJSON(v any) (string, error) {
+	var b strings.Builder
+	if err := encodeJSON(&b, v); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// formatCSV renders the array as a line of comma-separated values. Strings
+// and date-times are quoted with double quotes doubled inside of them.
+func formatCSV(v any) (string, error) {
+	return row(v, "@csv", ",", func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	})
+}
+
+// tsvReplacer escapes characters that would break a line of tab-separated
+// values.
+var tsvReplacer = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// formatTSV renders the array as a line of tab-separated values. Backslashes,
+// tabs and line breaks in strings and date-times are escaped.
+func formatTSV(v any) (string, error) {
+	return row(v, "@tsv", "\t", tsvReplacer.Replace)
+}
+
+// formatSh renders the string or the array of values as words for the POSIX
+// shell. Strings and date-times are quoted with single quotes, so that the
+// shell takes them literally.
+func formatSh(v any) (string, error) {
+	if _, ok := v.([]any); !ok {
+		v = []any{v}
+	}
+	return row(v, "@sh", " ", func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	})
+}
+
+// row joins the elements of the array with the separator. Strings and
+// date-times are rendered with the quote function, and numbers and booleans
+// are rendered as they are. Nested arrays and tables cannot be rendered.
+func row(v any, name, sep string, quote func(s string) string) (string, error) {
+	arr, ok := v.([]any)
+	if !ok {
+		return "", typeError(v, name)
+	}
+	fields := make([]string, 0, len(arr))
+	for _, e := range arr {
+		switch e.(type) {
+		case []any, map[string]any:
+			return "", typeError(e, name)
+		}
+		s, err := text(e)
+		if err != nil {
+			return "", err
+		}
+		switch e.(type) {
+		case string, time.Time, toml.LocalDateTime, toml.LocalDate, toml.LocalTime:
+			s = quote(s)
+		}
+		fields = append(fields, s)
+	}
+	return strings.Join(fields, sep), nil
+}
+
+// formatBase64 renders the value converted to text in base64.
+func formatBase64(v any) (string, error) {
+	s, err := text(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString([]byte(s)), nil
+}
+
+// formatBase64Decode decodes the value converted to text from base64 with or
+// without padding. The decoded bytes have to make up a valid UTF-8 string.
+func formatBase64Decode(v any) (string, error) {
+	s, err := text(v)
+	if err != nil {
+		return "", err
+	}
+	b, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil || !utf8.Valid(b) {
+		return "", &Error{data: v, filter: "@base64d", err: ErrConversion}
+	}
+	return string(b), nil
+}
+
+// formatURI renders the value converted to text with all characters other
+// than unreserved ones percent-encoded.
+func formatURI(v any) (string, error) {
+	s, err := text(v)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String(), nil
+}
+
+// htmlReplacer escapes characters with a special meaning in HTML.
+var htmlReplacer = strings.NewReplacer(
+	"<", "&lt;",
+	">", "&gt;",
+	"&", "&amp;",
+	"'", "&#39;",
+	`"`, "&quot;",
+)
+
+// formatHTML renders the value converted to text with HTML special characters
+// escaped.
+func formatHTML(v any) (string, error) {
+	s, err := text(v)
+	if err != nil {
+		return "", err
+	}
+	return htmlReplacer.Replace(s), nil
+}
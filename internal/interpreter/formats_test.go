@@ -0,0 +1,94 @@
+package interpreter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Test format strings rendering TOML values and interpolated strings.
+func TestFormats(t *testing.T) {
+	data := map[string]any{
+		"row":   []any{"a,b", `say "hi"`, int64(1), 0.5, true},
+		"tabs":  []any{"a\tb", "c\\d\ne"},
+		"file":  "it's a file.txt",
+		"files": []any{"a b", "it's", int64(1)},
+		"since": time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+		"html":  `<a href="x">'&'</a>`,
+		"query": "a b&c=d/é",
+		"table": map[string]any{"b": []any{int64(1)}, "a": "x"},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`.row | @csv`, []any{`"a,b","say ""hi""",1,0.5,true`}},
+		{`.tabs | @tsv`, []any{`a\tb	c\\d\ne`}},
+		{`.file | @sh`, []any{`'it'\''s a file.txt'`}},
+		{`.files | @sh`, []any{`'a b' 'it'\''s' 1`}},
+		{`[.since] | @csv`, []any{`"1979-05-27T07:32:00Z"`}},
+		{`[inf, -inf, nan] | @csv`, []any{"inf,-inf,nan"}},
+		{`[inf, nan] | @tsv`, []any{"inf\tnan"}},
+		{`[inf, nan] | @sh`, []any{"inf nan"}},
+		{`nan | @text`, []any{"nan"}},
+		{`[inf, -inf, nan] | @json`, []any{"[1.7976931348623157e+308,-1.7976931348623157e+308,null]"}},
+		{`.table | @json`, []any{`{"a":"x","b":[1]}`}},
+		{`.file | @json`, []any{`"it's a file.txt"`}},
+		{`.table | @text`, []any{`{"a":"x","b":[1]}`}},
+		{`.file | @text`, []any{"it's a file.txt"}},
+		{`.file | @base64`, []any{"aXQncyBhIGZpbGUudHh0"}},
+		{`.file | @base64 | @base64d`, []any{"it's a file.txt"}},
+		{`"YQ" | @base64d`, []any{"a"}},
+		{`.query | @uri`, []any{"a%20b%26c%3Dd%2F%C3%A9"}},
+		{`.html | @html`, []any{"&lt;a href=&quot;x&quot;&gt;&#39;&amp;&#39;&lt;/a&gt;"}},
+		{`@sh "rm -- \(.file) \(.files[0])"`, []any{`rm -- 'it'\''s a file.txt' 'a b'`}},
+		{`@uri "https://host/?q=\(.query)&n=\(1)"`, []any{"https://host/?q=a%20b%26c%3Dd%2F%C3%A9&n=1"}},
+		{`@json "value: \(.row[1])"`, []any{`value: "say \"hi\""`}},
+		{`@html "<b>\(.html)</b>"`, []any{"<b>&lt;a href=&quot;x&quot;&gt;&#39;&amp;&#39;&lt;/a&gt;</b>"}},
+		{`@csv "'\(.row)'"`, []any{`'"a,b","say ""hi""",1,0.5,true'`}},
+		{`@base64 "plain"`, []any{"plain"}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if format strings report errors for values they cannot render and
+// for names of formats that do not exist.
+func TestFormatsError(t *testing.T) {
+	data := map[string]any{
+		"name":   "web",
+		"nested": []any{[]any{"a"}},
+		"tables": []any{map[string]any{}},
+	}
+	cases := []struct {
+		query string
+		want  error
+	}{
+		{`.name | @csv`, ErrTOMLDataType},
+		{`.nested | @tsv`, ErrTOMLDataType},
+		{`.tables | @sh`, ErrTOMLDataType},
+		{`.nested | @sh`, ErrTOMLDataType},
+		{`"!" | @base64d`, ErrConversion},
+		{`"/w==" | @base64d`, ErrConversion},
+		{`.name | @yaml`, ErrFormatUndefined},
+		{`@yaml "\(.name)"`, ErrFormatUndefined},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			_, err := run(t, c.query, data)
+			if !errors.Is(err, c.want) {
+				t.Errorf("have: %v; want: %v", err, c.want)
+			}
+		})
+	}
+}
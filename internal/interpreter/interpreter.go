@@ -1,25 +1,129 @@
 package interpreter
 
 import (
+	"errors"
+	"maps"
+	"sort"
+	"strings"
+
 	"github.com/mdm-code/tq/v2/internal/ast"
 )
 
 // FilterFunc specifies the data transformation function type.
 type FilterFunc func(data ...any) ([]any, error)
 
+// emitter receives output values of a filter one at a time. The error it
+// returns stops the filter, and the filter returns this very error.
+type emitter func(v any) error
+
+// stream runs a filter against a single input value and passes its outputs to
+// the emitter as soon as they are produced. It lets generators stop producing
+// values once no more of them are needed.
+type stream func(data any, emit emitter) error
+
 type filter struct {
 	name  string
-	inner FilterFunc
+	inner stream
 }
 
 func (f *filter) call(data ...any) ([]any, error) {
-	return f.inner(data...)
+	return collect(f.inner, data...)
+}
+
+// collect runs the stream s against every input value and gathers all of its
+// outputs in a slice.
+func collect(s stream, data ...any) ([]any, error) {
+	result := make([]any, 0, len(data))
+	for _, d := range data {
+		err := s(d, func(v any) error {
+			result = append(result, v)
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
 }
 
 // Interpreter interprets the tq query AST into a pipe-like sequence of
 // filtering functions processing TOML input data as specified in the query.
 type Interpreter struct {
 	filters []filter
+	scope   *scope
+	slots   map[string]*variable
+	matcher matcher
+	err     error
+}
+
+// scope links the name of a variable or a function visible in the query being
+// interpreted to the slot holding its value while filters run or to the
+// definition of the function. Variables are named with the $ character and
+// functions with their signatures. Scopes nest, and inner names shadow outer
+// ones.
+type scope struct {
+	name   string
+	slot   *variable
+	fn     *function
+	parent *scope
+}
+
+// lookup returns the innermost scope entry with the given name.
+func (s *scope) lookup(name string) (*scope, bool) {
+	for ; s != nil; s = s.parent {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// visible returns slots of all variables and parameters visible in the scope.
+func (s *scope) visible() frame {
+	var f frame
+	for ; s != nil; s = s.parent {
+		if s.slot != nil {
+			f = append(f, s.slot)
+		}
+	}
+	return f
+}
+
+// function is the function defined in the query. Its parameters are slots
+// holding closures of arguments of the call being run.
+type function struct {
+	params frame
+	body   stream
+}
+
+// closure is the argument of the call of the function defined in the query.
+// It runs with values that variables visible at the call site had at the time
+// of the call, so that the function sees them the way the caller does.
+type closure struct {
+	inner  stream
+	slots  frame
+	values []variable
+}
+
+// run runs the argument against the data with variables visible at the call
+// site bound to their values from the time of the call. Current values are
+// restored while outputs pass back to the function.
+func (c *closure) run(data any, emit emitter) error {
+	curr := c.slots.save()
+	c.slots.restore(c.values)
+	defer c.slots.restore(curr)
+	return c.inner(data, func(v any) error {
+		c.slots.restore(curr)
+		defer c.slots.restore(c.values)
+		return emit(v)
+	})
+}
+
+// variable holds the value currently bound to the variable. Variables that
+// appear only in alternative patterns that did not match hold no value.
+type variable struct {
+	value any
+	bound bool
 }
 
 // New returns a new instance of Interpreter.
@@ -27,27 +131,93 @@ func New() *Interpreter {
 	return &Interpreter{}
 }
 
+// frame is the set of variables bound by a single binding.
+type frame []*variable
+
+// save returns a copy of values currently bound to variables of the frame.
+func (f frame) save() []variable {
+	vs := make([]variable, len(f))
+	for n, v := range f {
+		vs[n] = *v
+	}
+	return vs
+}
+
+// restore binds variables of the frame to values saved before. Without
+// saved values, it leaves variables unbound.
+func (f frame) restore(vs []variable) {
+	for n, v := range f {
+		if vs == nil {
+			*v = variable{}
+			continue
+		}
+		*v = vs[n]
+	}
+}
+
+// matcher destructures the value v matched against the pattern. It binds
+// variables of the pattern to parts of v and calls bind for every set of
+// bindings. Keys computed in the pattern run against the input data.
+type matcher func(data, v any, bind func() error) error
+
 func (i *Interpreter) eval(es ...ast.Expr) {
 	for _, e := range es {
 		e.Accept(i)
 	}
 }
 
+// compile interprets the expression e into a standalone stream leaving the
+// sequence of filters accumulated so far intact.
+func (i *Interpreter) compile(e ast.Expr) stream {
+	prev := i.filters
+	i.filters = nil
+	i.eval(e)
+	fn := chain(i.filters)
+	i.filters = prev
+	return fn
+}
+
+// chain returns a stream applying filters fs in sequence, where every output
+// of one filter is the input of the next one.
+func chain(fs []filter) stream {
+	return func(data any, emit emitter) error {
+		var run func(n int, d any) error
+		run = func(n int, d any) error {
+			if n == len(fs) {
+				return emit(d)
+			}
+			return fs[n].inner(d, func(v any) error {
+				return run(n+1, v)
+			})
+		}
+		return run(0, data)
+	}
+}
+
 // Interpret extracts a sequence of filtering functions by traversing the AST.
 // It returns an entry function that takes in deserialized TOML data and
 // applies filtering functions in the sequence provided by the Interpreter.
-func (i *Interpreter) Interpret(root ast.Expr) FilterFunc {
+// Functions, variables and formats that the query refers to are resolved
+// here, so undefined names are reported before any data is queried.
+func (i *Interpreter) Interpret(root ast.Expr) (FilterFunc, error) {
 	i.filters = nil // clear out previously accumulated filtering functions
+	i.scope = nil
+	i.err = nil
 	i.eval(root)
+	if i.err != nil {
+		return nil, i.err
+	}
+	s := chain(i.filters)
 	return func(data ...any) ([]any, error) {
-		var err error
-		for _, f := range i.filters {
-			data, err = f.call(data...)
-			if err != nil {
-				return data, err
-			}
-		}
-		return data, nil
+		return collect(s, data...)
+	}, nil
+}
+
+// fail records the error found while interpreting the AST. Only the first
+// error is kept.
+func (i *Interpreter) fail(err error) {
+	if i.err == nil {
+		i.err = err
 	}
 }
 
@@ -63,23 +233,704 @@ func (i *Interpreter) VisitQuery(e ast.Expr) {
 	i.eval(q.Filters...)
 }
 
+// VisitPipe interprets the Pipe AST node.
+func (i *Interpreter) VisitPipe(e ast.Expr) {
+	p := e.(*ast.Pipe)
+	left, right := i.compile(p.Left), i.compile(p.Right)
+	f := filter{
+		name: "pipe",
+		inner: func(data any, emit emitter) error {
+			return left(data, func(v any) error {
+				return right(v, emit)
+			})
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// VisitComma interprets the Comma AST node.
+func (i *Interpreter) VisitComma(e ast.Expr) {
+	c := e.(*ast.Comma)
+	left, right := i.compile(c.Left), i.compile(c.Right)
+	f := filter{
+		name: "comma",
+		inner: func(data any, emit emitter) error {
+			if err := left(data, emit); err != nil {
+				return err
+			}
+			return right(data, emit)
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// VisitBinary interprets the Binary AST node.
+func (i *Interpreter) VisitBinary(e ast.Expr) {
+	b := e.(*ast.Binary)
+	left, right := i.compile(b.Left), i.compile(b.Right)
+	op := binaryOperators[b.Operator]
+	f := filter{
+		name: "binary",
+		inner: func(data any, emit emitter) error {
+			return right(data, func(r any) error {
+				return left(data, func(l any) error {
+					v, err := op(l, r)
+					if err != nil {
+						return &OperandError{l, r, b.String(), err}
+					}
+					return emit(v)
+				})
+			})
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// VisitNegation interprets the Negation AST node.
+func (i *Interpreter) VisitNegation(e ast.Expr) {
+	n := e.(*ast.Negation)
+	value := i.compile(n.Value)
+	f := filter{
+		name: n.String(),
+		inner: func(data any, emit emitter) error {
+			return value(data, func(v any) error {
+				r, err := negate(v)
+				if err != nil {
+					return &Error{data: v, filter: n.String(), err: err}
+				}
+				return emit(r)
+			})
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// binaryOperators maps binary operators onto functions applying them to their
+// operands.
+var binaryOperators = map[string]func(l, r any) (any, error){
+	"==": func(l, r any) (any, error) { return equal(l, r), nil },
+	"!=": func(l, r any) (any, error) { return !equal(l, r), nil },
+	"<":  ordering(func(c int) bool { return c < 0 }),
+	"<=": ordering(func(c int) bool { return c <= 0 }),
+	">":  ordering(func(c int) bool { return c > 0 }),
+	">=": ordering(func(c int) bool { return c >= 0 }),
+	"+":  add,
+	"-":  subtract,
+	"*":  multiply,
+	"/":  divide,
+	"%":  modulo,
+}
+
+// ordering returns an ordering comparison operator function reporting the
+// outcome of the comparison of its operands with the predicate fn.
+func ordering(fn func(int) bool) func(l, r any) (any, error) {
+	return func(l, r any) (any, error) {
+		c, ok := compare(l, r)
+		if !ok {
+			return nil, ErrTOMLDataType
+		}
+		return fn(c), nil
+	}
+}
+
+// VisitLogical interprets the Logical AST node.
+func (i *Interpreter) VisitLogical(e ast.Expr) {
+	l := e.(*ast.Logical)
+	left, right := i.compile(l.Left), i.compile(l.Right)
+	// NOTE: The left-hand side output that settles the result on its own is
+	// true for the disjunction and false for the conjunction.
+	settles := l.Operator == "or"
+	f := filter{
+		name: "logical",
+		inner: func(data any, emit emitter) error {
+			return left(data, func(lv any) error {
+				if truthy(lv) == settles {
+					return emit(settles)
+				}
+				return right(data, func(rv any) error {
+					return emit(truthy(rv))
+				})
+			})
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// VisitCall interprets the Call AST node. Functions defined in the query
+// shadow builtin functions with the same signature. The call of an undefined
+// function without arguments that opens the root query falls back to the key
+// lookup, so bare strings opening the query keep selecting keys of the input
+// table.
+func (i *Interpreter) VisitCall(e ast.Expr) {
+	c := e.(*ast.Call)
+	if s, ok := i.scope.lookup(signature(c.Name, len(c.Args))); ok {
+		i.invoke(c, s)
+		return
+	}
+	fn, ok := builtins[signature(c.Name, len(c.Args))]
+	switch {
+	case !ok && len(c.Args) == 0 && c.Root:
+		i.eval(&ast.String{Value: c.Name})
+		return
+	case !ok:
+		i.fail(&Error{filter: c.String(), err: ErrFunctionUndefined})
+		return
+	}
+	args := make([]stream, len(c.Args))
+	for n, arg := range c.Args {
+		args[n] = i.compile(arg)
+	}
+	f := filter{
+		name: c.String(),
+		inner: func(data any, emit emitter) error {
+			return fn(data, args, emit)
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// invoke interprets the call of the function defined in the query or of the
+// filter parameter of such function. Parameters of the function are bound to
+// closures of arguments while its body runs, and their previous values are
+// restored while outputs pass downstream, so that recursive calls keep their
+// own arguments.
+func (i *Interpreter) invoke(c *ast.Call, s *scope) {
+	if s.fn == nil {
+		slot := s.slot
+		f := filter{
+			name: c.String(),
+			inner: func(data any, emit emitter) error {
+				return slot.value.(*closure).run(data, emit)
+			},
+		}
+		i.filters = append(i.filters, f)
+		return
+	}
+	fn := s.fn
+	args := make([]stream, len(c.Args))
+	for n, arg := range c.Args {
+		args[n] = i.compile(arg)
+	}
+	visible := i.scope.visible()
+	f := filter{
+		name: c.String(),
+		inner: func(data any, emit emitter) error {
+			values := visible.save()
+			closures := make([]variable, len(args))
+			for n, arg := range args {
+				closures[n] = variable{value: &closure{arg, visible, values}, bound: true}
+			}
+			prev := fn.params.save()
+			fn.params.restore(closures)
+			defer fn.params.restore(prev)
+			return fn.body(data, func(v any) error {
+				curr := fn.params.save()
+				fn.params.restore(prev)
+				defer fn.params.restore(curr)
+				return emit(v)
+			})
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// VisitDefinition interprets the Definition AST node. The body of the function
+// is interpreted once in the scope of the definition, so that it can refer to
+// variables and functions visible there as well as to itself. The parameter
+// taking values binds the variable to every output of its argument, and it
+// can be called as a filter, too.
+func (i *Interpreter) VisitDefinition(e ast.Expr) {
+	d := e.(*ast.Definition)
+	outer := i.scope
+	fn := &function{}
+	i.scope = &scope{name: signature(d.Name, len(d.Params)), fn: fn, parent: i.scope}
+	defined := i.scope
+	body := d.Body
+	for n := len(d.Params) - 1; n >= 0; n-- {
+		if name, ok := strings.CutPrefix(d.Params[n], "$"); ok {
+			body = &ast.Binding{
+				Source:   &ast.Call{Name: name},
+				Patterns: []ast.Expr{&ast.Variable{Name: name}},
+				Body:     body,
+			}
+		}
+	}
+	for _, p := range d.Params {
+		slot := &variable{}
+		fn.params = append(fn.params, slot)
+		name := signature(strings.TrimPrefix(p, "$"), 0)
+		i.scope = &scope{name: name, slot: slot, parent: i.scope}
+	}
+	fn.body = i.compile(body)
+	i.scope = defined
+	i.eval(d.Rest)
+	i.scope = outer
+}
+
+// VisitObject interprets the Object AST node.
+func (i *Interpreter) VisitObject(e ast.Expr) {
+	o := e.(*ast.Object)
+	keys := make([]stream, len(o.Entries))
+	values := make([]stream, len(o.Entries))
+	for n, entry := range o.Entries {
+		keys[n], values[n] = i.compile(entry.Key), i.compile(entry.Value)
+	}
+	f := filter{
+		name: "object",
+		inner: func(data any, emit emitter) error {
+			// NOTE: Entries are added one by one, and every output of the key
+			// and the value of an entry makes up a table of its own.
+			var build func(n int, table map[string]any) error
+			build = func(n int, table map[string]any) error {
+				if n == len(o.Entries) {
+					return emit(table)
+				}
+				return keys[n](data, func(k any) error {
+					key, ok := k.(string)
+					if !ok {
+						return &Error{data: k, filter: "object key", err: ErrTOMLDataType}
+					}
+					return values[n](data, func(v any) error {
+						next := maps.Clone(table)
+						next[key] = v
+						return build(n+1, next)
+					})
+				})
+			}
+			return build(0, map[string]any{})
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// VisitArray interprets the Array AST node.
+func (i *Interpreter) VisitArray(e ast.Expr) {
+	a := e.(*ast.Array)
+	inner := stream(nothing)
+	if a.Value != nil {
+		inner = i.compile(a.Value)
+	}
+	f := filter{
+		name: "array",
+		inner: func(data any, emit emitter) error {
+			vs, err := collect(inner, data)
+			if err != nil {
+				return err
+			}
+			return emit(vs)
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// VisitInterpolation interprets the Interpolation AST node.
+func (i *Interpreter) VisitInterpolation(e ast.Expr) {
+	in := e.(*ast.Interpolation)
+	parts := make([]stream, len(in.Parts))
+	for n, part := range in.Parts {
+		parts[n] = i.compile(part)
+	}
+	conv, ok := text, true
+	if in.Format != "" {
+		conv, ok = formats[in.Format]
+	}
+	if !ok {
+		i.fail(&Error{filter: "@" + in.Format, err: ErrFormatUndefined})
+		return
+	}
+	f := filter{
+		name: "interpolation",
+		inner: func(data any, emit emitter) error {
+			// NOTE: Parts are joined one by one, and every output of a part
+			// makes up a string of its own.
+			var build func(n int, s string) error
+			build = func(n int, s string) error {
+				if n == len(parts) {
+					return emit(s)
+				}
+				if l, ok := in.Parts[n].(*ast.Literal); ok {
+					return build(n+1, s+l.Value.(string))
+				}
+				return parts[n](data, func(v any) error {
+					t, err := conv(v)
+					if err != nil {
+						return err
+					}
+					return build(n+1, s+t)
+				})
+			}
+			return build(0, "")
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// VisitFormat interprets the Format AST node.
+func (i *Interpreter) VisitFormat(e ast.Expr) {
+	fm := e.(*ast.Format)
+	conv, ok := formats[fm.Name]
+	if !ok {
+		i.fail(&Error{filter: fm.String(), err: ErrFormatUndefined})
+		return
+	}
+	f := filter{
+		name: fm.String(),
+		inner: func(data any, emit emitter) error {
+			s, err := conv(data)
+			if err != nil {
+				return err
+			}
+			return emit(s)
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// VisitBinding interprets the Binding AST node. The source runs against the
+// input data, and the body runs against the same data once for each output
+// of the source with that output bound to the pattern.
+func (i *Interpreter) VisitBinding(e ast.Expr) {
+	b := e.(*ast.Binding)
+	source := i.compile(b.Source)
+	outer := i.scope
+	vars := i.bind(b.Patterns)
+	body := i.compile(b.Body)
+	i.scope = outer
+	f := filter{
+		name: "binding",
+		inner: func(data any, emit emitter) error {
+			prev := vars.slots.save()
+			defer vars.slots.restore(prev)
+			var downstream error
+			return source(data, func(x any) error {
+				return vars.each(data, x, &downstream, func() error {
+					return body(data, func(v any) error {
+						downstream = vars.pass(prev, v, emit)
+						return downstream
+					})
+				})
+			})
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// VisitReduce interprets the Reduce AST node. Every output of the init
+// expression starts a reduction of its own. Each output of the update
+// expression becomes the state in turn, and the update expression without
+// outputs leaves the state as it is.
+func (i *Interpreter) VisitReduce(e ast.Expr) {
+	r := e.(*ast.Reduce)
+	source, init := i.compile(r.Source), i.compile(r.Init)
+	outer := i.scope
+	vars := i.bind(r.Patterns)
+	update := i.compile(r.Update)
+	i.scope = outer
+	f := filter{
+		name: r.String(),
+		inner: func(data any, emit emitter) error {
+			prev := vars.slots.save()
+			defer vars.slots.restore(prev)
+			return init(data, func(state any) error {
+				err := source(data, func(x any) error {
+					return vars.each(data, x, nil, func() error {
+						return update(state, func(v any) error {
+							state = v
+							return nil
+						})
+					})
+				})
+				if err != nil {
+					return err
+				}
+				vars.slots.restore(prev)
+				return emit(state)
+			})
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// VisitForeach interprets the Foreach AST node. It runs the reduction the way
+// VisitReduce does, and the extract expression runs against every state the
+// update expression produces along the way.
+func (i *Interpreter) VisitForeach(e ast.Expr) {
+	fe := e.(*ast.Foreach)
+	source, init := i.compile(fe.Source), i.compile(fe.Init)
+	outer := i.scope
+	vars := i.bind(fe.Patterns)
+	update, extract := i.compile(fe.Update), stream(identity)
+	if fe.Extract != nil {
+		extract = i.compile(fe.Extract)
+	}
+	i.scope = outer
+	f := filter{
+		name: fe.String(),
+		inner: func(data any, emit emitter) error {
+			prev := vars.slots.save()
+			defer vars.slots.restore(prev)
+			return init(data, func(state any) error {
+				var downstream error
+				return source(data, func(x any) error {
+					return vars.each(data, x, &downstream, func() error {
+						return update(state, func(v any) error {
+							state = v
+							return extract(v, func(out any) error {
+								downstream = vars.pass(prev, out, emit)
+								return downstream
+							})
+						})
+					})
+				})
+			})
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// binder binds values to variables of alternative patterns.
+type binder struct {
+	slots    frame
+	matchers []matcher
+}
+
+// bind interprets alternative patterns into the binder and brings their
+// variables into scope. The caller restores the scope after it interprets
+// expressions that refer to these variables.
+func (i *Interpreter) bind(patterns []ast.Expr) *binder {
+	enclosing := i.slots
+	i.slots = map[string]*variable{}
+	b := binder{matchers: make([]matcher, len(patterns))}
+	for n, p := range patterns {
+		b.matchers[n] = i.destructure(p)
+	}
+	b.slots = make(frame, 0, len(i.slots))
+	for name, slot := range i.slots {
+		i.scope = &scope{name: "$" + name, slot: slot, parent: i.scope}
+		b.slots = append(b.slots, slot)
+	}
+	i.slots = enclosing
+	return &b
+}
+
+// each binds the value x to the first alternative pattern that matches it and
+// calls body for every set of bindings. An error raised by the pattern or the
+// body moves on to the next alternative unless it is the downstream error
+// returned by filters that follow.
+func (b *binder) each(data, x any, downstream *error, body func() error) error {
+	for n, m := range b.matchers {
+		matched := false
+		b.slots.restore(nil)
+		err := m(data, x, func() error {
+			matched = true
+			return body()
+		})
+		if downstream != nil && *downstream != nil {
+			return *downstream
+		}
+		if n == len(b.matchers)-1 || err == nil && matched {
+			return err
+		}
+	}
+	return nil
+}
+
+// pass passes the value v downstream. Variables are bound to values saved
+// before while v passes, so that the binder that is run again before its
+// previous run is over keeps both sets of values.
+func (b *binder) pass(prev []variable, v any, emit emitter) error {
+	curr := b.slots.save()
+	b.slots.restore(prev)
+	defer b.slots.restore(curr)
+	return emit(v)
+}
+
+// destructure interprets the pattern into the matcher binding variables of
+// the binding being interpreted.
+func (i *Interpreter) destructure(e ast.Expr) matcher {
+	r, ok := e.(*ast.Variable)
+	if !ok {
+		e.Accept(i)
+		return i.matcher
+	}
+	slot := i.slot(r.Name)
+	return func(_, v any, bind func() error) error {
+		*slot = variable{value: v, bound: true}
+		return bind()
+	}
+}
+
+// slot returns the variable of the given name bound by the binding being
+// interpreted. Variables repeated across the pattern share the same slot.
+func (i *Interpreter) slot(name string) *variable {
+	if _, ok := i.slots[name]; !ok {
+		i.slots[name] = &variable{}
+	}
+	return i.slots[name]
+}
+
+// VisitArrayPattern interprets the ArrayPattern AST node. Elements missing
+// from the array leave the pattern unmatched.
+func (i *Interpreter) VisitArrayPattern(e ast.Expr) {
+	a := e.(*ast.ArrayPattern)
+	elements := make([]matcher, len(a.Elements))
+	for n, el := range a.Elements {
+		elements[n] = i.destructure(el)
+	}
+	i.matcher = func(data, v any, bind func() error) error {
+		arr, ok := v.([]any)
+		if !ok {
+			return &Error{data: v, filter: a.String(), err: ErrTOMLDataType}
+		}
+		var match func(n int) error
+		match = func(n int) error {
+			if n == len(elements) {
+				return bind()
+			}
+			if n >= len(arr) {
+				return nil
+			}
+			return elements[n](data, arr[n], func() error {
+				return match(n + 1)
+			})
+		}
+		return match(0)
+	}
+}
+
+// VisitObjectPattern interprets the ObjectPattern AST node. Keys missing from
+// the table leave the pattern unmatched, and every output of a computed key
+// makes up a set of bindings of its own.
+func (i *Interpreter) VisitObjectPattern(e ast.Expr) {
+	o := e.(*ast.ObjectPattern)
+	keys := make([]stream, len(o.Entries))
+	slots := make([]*variable, len(o.Entries))
+	values := make([]matcher, len(o.Entries))
+	for n, entry := range o.Entries {
+		if r, ok := entry.Key.(*ast.Variable); ok {
+			keys[n], slots[n] = literal(r.Name), i.slot(r.Name)
+		} else {
+			keys[n] = i.compile(entry.Key)
+		}
+		if entry.Value != nil {
+			values[n] = i.destructure(entry.Value)
+		}
+	}
+	i.matcher = func(data, v any, bind func() error) error {
+		table, ok := v.(map[string]any)
+		if !ok {
+			return &Error{data: v, filter: o.String(), err: ErrTOMLDataType}
+		}
+		var match func(n int) error
+		match = func(n int) error {
+			if n == len(o.Entries) {
+				return bind()
+			}
+			return keys[n](data, func(k any) error {
+				key, ok := k.(string)
+				if !ok {
+					return &Error{data: k, filter: "object key", err: ErrTOMLDataType}
+				}
+				val, ok := table[key]
+				if !ok {
+					return nil
+				}
+				if slots[n] != nil {
+					*slots[n] = variable{value: val, bound: true}
+				}
+				if values[n] == nil {
+					return match(n + 1)
+				}
+				return values[n](data, val, func() error {
+					return match(n + 1)
+				})
+			})
+		}
+		return match(0)
+	}
+}
+
+// VisitVariable interprets the Variable AST node.
+func (i *Interpreter) VisitVariable(e ast.Expr) {
+	r := e.(*ast.Variable)
+	s, ok := i.scope.lookup("$" + r.Name)
+	if !ok {
+		i.fail(&Error{filter: "$" + r.Name, err: ErrVariableUndefined})
+		return
+	}
+	f := filter{
+		name: r.String(),
+		inner: func(data any, emit emitter) error {
+			if !s.slot.bound {
+				return nil
+			}
+			return emit(s.slot.value)
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
+// VisitLiteral interprets the Literal AST node.
+func (i *Interpreter) VisitLiteral(e ast.Expr) {
+	l := e.(*ast.Literal)
+	f := filter{
+		name: "literal",
+		inner: func(_ any, emit emitter) error {
+			return emit(l.Value)
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
 // VisitFilter interprets the Filter AST node.
 func (i *Interpreter) VisitFilter(e ast.Expr) {
 	f := e.(*ast.Filter)
 	i.eval(f.Kind)
 }
 
+// VisitOptional interprets the Optional AST node.
+func (i *Interpreter) VisitOptional(e ast.Expr) {
+	o := e.(*ast.Optional)
+	inner := i.compile(o.Value)
+	f := filter{
+		name: "optional",
+		inner: func(data any, emit emitter) error {
+			// NOTE: Errors returned by filters that follow the optional one
+			// pass through the emitter, and they are never suppressed.
+			var downstream error
+			err := inner(data, func(v any) error {
+				downstream = emit(v)
+				return downstream
+			})
+			if downstream != nil {
+				return downstream
+			}
+			if err != nil && !errors.Is(err, ErrTOMLDataType) {
+				return err
+			}
+			return nil
+		},
+	}
+	i.filters = append(i.filters, f)
+}
+
 // VisitIdentity interprets the Identity AST node.
 func (i *Interpreter) VisitIdentity(e ast.Expr) {
 	f := filter{
-		name: "identity",
-		inner: func(data ...any) ([]any, error) {
-			return data, nil
-		},
+		name:  "identity",
+		inner: identity,
 	}
 	i.filters = append(i.filters, f)
 }
 
+func identity(data any, emit emitter) error {
+	return emit(data)
+}
+
+func nothing(any, emitter) error {
+	return nil
+}
+
 // VisitSelector interprets the Selector AST node.
 func (i *Interpreter) VisitSelector(e ast.Expr) {
 	s := e.(*ast.Selector)
@@ -91,89 +942,163 @@ func (i *Interpreter) VisitSpan(e ast.Expr) {
 	span := e.(*ast.Span)
 	f := filter{
 		name: "span",
-		inner: func(data ...any) ([]any, error) {
-			result := make([]any, 0, len(data))
-			var err error
-			for _, d := range data {
-				switch v := d.(type) {
-				case []any:
-					l, r := span.GetLeft(0), span.GetRight(len(v))
-					if r > len(v) {
-						r = len(v)
-					}
-					if l > r || l >= len(v) {
-						continue
-					}
-					result = append(result, v[l:r])
-				default:
-					err = &Error{
-						data:   d,
-						filter: span.String(),
-						err:    ErrTOMLDataType,
-					}
+		inner: func(data any, emit emitter) error {
+			switch v := data.(type) {
+			case []any:
+				return emit(slice(v, span))
+			case string:
+				return emit(string(slice([]rune(v), span)))
+			default:
+				return &Error{
+					data:   data,
+					filter: span.String(),
+					err:    ErrTOMLDataType,
 				}
 			}
-			return result, err
 		},
 	}
 	i.filters = append(i.filters, f)
 }
 
+// spanIndices resolves the span against a sequence of length n the way
+// Python does it with slices. Negative bounds count back from the end of the
+// sequence, and bounds out of range are clamped to the sequence. It returns
+// the start index, the exclusive stop index, and the step.
+func spanIndices(span *ast.Span, n int) (int, int, int) {
+	step := span.GetStep(1)
+	if step == 0 {
+		step = 1 // NOTE: The parser does not let through zero steps.
+	}
+	lower, upper := 0, n
+	if step < 0 {
+		lower, upper = -1, n-1
+	}
+	start, stop := lower, upper
+	if step < 0 {
+		start, stop = upper, lower
+	}
+	if span.Left != nil {
+		start = clampBound(span.GetLeft(0), n, lower, upper)
+	}
+	if span.Right != nil {
+		stop = clampBound(span.GetRight(0), n, lower, upper)
+	}
+	return start, stop, step
+}
+
+// clampBound resolves the possibly negative span bound i against a sequence
+// of length n and clamps it to the range between lower and upper.
+func clampBound(i, n, lower, upper int) int {
+	if i < 0 {
+		i += n
+	}
+	return min(max(i, lower), upper)
+}
+
+// slice returns a new slice of elements of v selected with the span.
+func slice[T any](v []T, span *ast.Span) []T {
+	start, stop, step := spanIndices(span, len(v))
+	result := []T{}
+	for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+		result = append(result, v[i])
+	}
+	return result
+}
+
 // VisitIterator interprets the Iterator AST node.
 func (i *Interpreter) VisitIterator(e ast.Expr) {
 	iter := e.(*ast.Iterator)
 	f := filter{
 		name: "iterator",
-		inner: func(data ...any) ([]any, error) {
-			result := make([]any, 0, len(data))
-			var err error
-			for _, d := range data {
-				switch v := d.(type) {
-				case map[string]any:
-					for _, val := range v {
-						result = append(result, val)
+		inner: func(data any, emit emitter) error {
+			switch v := data.(type) {
+			case map[string]any:
+				for _, key := range sortedKeys(v) {
+					if err := emit(v[key]); err != nil {
+						return err
 					}
-				case []any:
-					result = append(result, v...)
-				default:
-					err = &Error{
-						data:   d,
-						filter: iter.String(),
-						err:    ErrTOMLDataType,
+				}
+			case []any:
+				for _, val := range v {
+					if err := emit(val); err != nil {
+						return err
 					}
 				}
+			default:
+				return &Error{
+					data:   data,
+					filter: iter.String(),
+					err:    ErrTOMLDataType,
+				}
 			}
-			return result, err
+			return nil
 		},
 	}
 	i.filters = append(i.filters, f)
 }
 
+// VisitRecurse interprets the Recurse AST node.
+func (i *Interpreter) VisitRecurse(e ast.Expr) {
+	f := filter{
+		name:  "recurse",
+		inner: descend,
+	}
+	i.filters = append(i.filters, f)
+}
+
+// descend emits the data followed by all of its nested values going
+// depth-first. Tables are descended in the order of their keys.
+func descend(data any, emit emitter) error {
+	if err := emit(data); err != nil {
+		return err
+	}
+	switch v := data.(type) {
+	case map[string]any:
+		for _, key := range sortedKeys(v) {
+			if err := descend(v[key], emit); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, val := range v {
+			if err := descend(val, emit); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the table m in ascending order. It makes the
+// order of values retrieved from a table deterministic.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // VisitString interprets the String AST node.
 func (i *Interpreter) VisitString(e ast.Expr) {
 	str := e.(*ast.String)
 	f := filter{
 		name: "string",
-		inner: func(data ...any) ([]any, error) {
-			result := make([]any, 0, len(data))
-			var err error
-			for _, d := range data {
-				switch v := d.(type) {
-				case map[string]any:
-					key := str.Value
-					res, ok := v[key]
-					if ok {
-						result = append(result, res)
-					}
-				default:
-					err = &Error{
-						data:   d,
-						filter: str.String(),
-						err:    ErrTOMLDataType,
-					}
+		inner: func(data any, emit emitter) error {
+			switch v := data.(type) {
+			case map[string]any:
+				if res, ok := v[str.Value]; ok {
+					return emit(res)
+				}
+				return nil
+			default:
+				return &Error{
+					data:   data,
+					filter: str.String(),
+					err:    ErrTOMLDataType,
 				}
 			}
-			return result, err
 		},
 	}
 	i.filters = append(i.filters, f)
@@ -184,25 +1109,34 @@ func (i *Interpreter) VisitInteger(e ast.Expr) {
 	integer := e.(*ast.Integer)
 	f := filter{
 		name: "integer",
-		inner: func(data ...any) ([]any, error) {
-			result := make([]any, 0, len(data))
-			var err error
-			for _, d := range data {
-				switch v := d.(type) {
-				case []any:
-					idx, _ := integer.Vtoi()
-					if idx >= 0 && idx < len(v) {
-						result = append(result, v[idx])
-					}
-				default:
-					err = &Error{
-						data:   d,
-						filter: integer.String(),
-						err:    ErrTOMLDataType,
-					}
+		inner: func(data any, emit emitter) error {
+			switch v := data.(type) {
+			case []any:
+				idx, _ := integer.Vtoi()
+				if idx < 0 {
+					idx += len(v)
+				}
+				if idx >= 0 && idx < len(v) {
+					return emit(v[idx])
+				}
+				return nil
+			case string:
+				runes := []rune(v)
+				idx, _ := integer.Vtoi()
+				if idx < 0 {
+					idx += len(runes)
+				}
+				if idx >= 0 && idx < len(runes) {
+					return emit(string(runes[idx]))
+				}
+				return nil
+			default:
+				return &Error{
+					data:   data,
+					filter: integer.String(),
+					err:    ErrTOMLDataType,
 				}
 			}
-			return result, err
 		},
 	}
 	i.filters = append(i.filters, f)
@@ -1,12 +1,45 @@
 package interpreter
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/mdm-code/scanner"
 	"github.com/mdm-code/tq/v2/internal/ast"
+	"github.com/mdm-code/tq/v2/internal/lexer"
+	"github.com/mdm-code/tq/v2/internal/parser"
+	"github.com/pelletier/go-toml/v2"
 )
 
+// run parses the query and interprets it against the data.
+func run(t *testing.T, query string, data any) ([]any, error) {
+	t.Helper()
+	s, err := scanner.New(strings.NewReader(query))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := lexer.New(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := parser.New(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec, err := New().Interpret(root)
+	if err != nil {
+		return nil, err
+	}
+	return exec(data)
+}
+
 // Test the public API of the Interpreter.
 func TestInterpret(t *testing.T) {
 	cases := []struct {
@@ -154,11 +187,231 @@ func TestInterpret(t *testing.T) {
 				},
 			},
 		},
+		{
+			data: map[string]any{
+				"servers": []any{
+					map[string]any{"ip": "10.0.0.1"},
+					map[string]any{"ip": "10.0.0.2"},
+				},
+			},
+			filteredData: []interface{}{"10.0.0.1", "10.0.0.2"},
+			query:        ".servers[] | .ip",
+			want:         []filter{{name: "pipe"}},
+			root: &ast.Root{
+				Query: &ast.Pipe{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.String{
+									Value: "servers",
+								},
+							},
+							&ast.Filter{
+								Kind: &ast.Selector{
+									Value: &ast.Iterator{},
+								},
+							},
+						},
+					},
+					Right: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.String{
+									Value: "ip",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			data: []any{
+				map[string]any{"host": "alpha", "port": int64(8080)},
+				map[string]any{"host": "beta", "port": int64(8081)},
+			},
+			filteredData: []interface{}{
+				"alpha", int64(8080), "beta", int64(8081),
+			},
+			query: ".[] | .host, .port",
+			want:  []filter{{name: "pipe"}},
+			root: &ast.Root{
+				Query: &ast.Pipe{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.Selector{
+									Value: &ast.Iterator{},
+								},
+							},
+						},
+					},
+					Right: &ast.Comma{
+						Left: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Identity{},
+								},
+								&ast.Filter{
+									Kind: &ast.String{
+										Value: "host",
+									},
+								},
+							},
+						},
+						Right: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Identity{},
+								},
+								&ast.Filter{
+									Kind: &ast.String{
+										Value: "port",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			data: map[string]any{
+				"b": []any{int64(1), map[string]any{"c": true}},
+				"a": "x",
+			},
+			filteredData: []interface{}{
+				map[string]any{
+					"b": []any{int64(1), map[string]any{"c": true}},
+					"a": "x",
+				},
+				"x",
+				[]any{int64(1), map[string]any{"c": true}},
+				int64(1),
+				map[string]any{"c": true},
+				true,
+			},
+			query: "..",
+			want:  []filter{{name: "recurse"}},
+			root: &ast.Root{
+				Query: &ast.Query{
+					Filters: []ast.Expr{
+						&ast.Filter{
+							Kind: &ast.Recurse{},
+						},
+					},
+				},
+			},
+		},
+		{
+			data: map[string]any{
+				"servers": []any{
+					map[string]any{"ip": "10.0.0.1", "tags": []any{"a", "b"}},
+					map[string]any{"ip": "10.0.0.2", "tags": "c"},
+					map[string]any{"ip": "10.0.0.3"},
+				},
+			},
+			filteredData: []interface{}{"a", "b"},
+			query:        ".servers[].tags[]?",
+			want: []filter{
+				{name: "identity"},
+				{name: "string"},
+				{name: "iterator"},
+				{name: "identity"},
+				{name: "string"},
+				{name: "optional"},
+			},
+			root: &ast.Root{
+				Query: &ast.Query{
+					Filters: []ast.Expr{
+						&ast.Filter{
+							Kind: &ast.Identity{},
+						},
+						&ast.Filter{
+							Kind: &ast.String{
+								Value: "servers",
+							},
+						},
+						&ast.Filter{
+							Kind: &ast.Selector{
+								Value: &ast.Iterator{},
+							},
+						},
+						&ast.Filter{
+							Kind: &ast.Identity{},
+						},
+						&ast.Filter{
+							Kind: &ast.String{
+								Value: "tags",
+							},
+						},
+						&ast.Filter{
+							Kind: &ast.Optional{
+								Value: &ast.Selector{
+									Value: &ast.Iterator{},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			data: map[string]any{
+				"servers": map[string]any{
+					"alpha": map[string]any{"ip": "10.0.0.1"},
+					"beta": map[string]any{
+						"ip":    "10.0.0.2",
+						"ports": []any{int64(80)},
+					},
+				},
+			},
+			filteredData: []interface{}{"10.0.0.1", "10.0.0.2"},
+			query:        ".. | .ip?",
+			want:         []filter{{name: "pipe"}},
+			root: &ast.Root{
+				Query: &ast.Pipe{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Recurse{},
+							},
+						},
+					},
+					Right: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.Optional{
+									Value: &ast.String{
+										Value: "ip",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.query, func(t *testing.T) {
 			i := New()
-			exec := i.Interpret(c.root)
+			exec, err := i.Interpret(c.root)
+			if err != nil {
+				t.Fatal(err)
+			}
 			have := i.filters
 			for i, f := range c.want {
 				if f.name != have[i].name {
@@ -176,6 +429,161 @@ func TestInterpret(t *testing.T) {
 	}
 }
 
+// Verify if index filters resolve negative indexes against arrays.
+func TestVisitInteger(t *testing.T) {
+	data := []any{"a", "b", "c", "d"}
+	cases := []struct {
+		index string
+		want  []any
+	}{
+		{"0", []any{"a"}},
+		{"3", []any{"d"}},
+		{"4", []any{}},
+		{"-1", []any{"d"}},
+		{"-4", []any{"a"}},
+		{"-5", []any{}},
+	}
+	for _, c := range cases {
+		t.Run(c.index, func(t *testing.T) {
+			i := New()
+			i.VisitInteger(&ast.Integer{Value: c.index})
+			have, err := i.filters[0].call(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if span filters resolve negative and out-of-range bounds the way
+// Python slices do.
+func TestVisitSpan(t *testing.T) {
+	data := []any{"a", "b", "c", "d"}
+	cases := []struct {
+		span *ast.Span
+		want []any
+	}{
+		{&ast.Span{}, []any{[]any{"a", "b", "c", "d"}}},
+		{&ast.Span{Left: &ast.Integer{Value: "1"}}, []any{[]any{"b", "c", "d"}}},
+		{&ast.Span{Right: &ast.Integer{Value: "99"}}, []any{[]any{"a", "b", "c", "d"}}},
+		{&ast.Span{Left: &ast.Integer{Value: "-3"}}, []any{[]any{"b", "c", "d"}}},
+		{&ast.Span{Right: &ast.Integer{Value: "-1"}}, []any{[]any{"a", "b", "c"}}},
+		{
+			&ast.Span{
+				Left:  &ast.Integer{Value: "-3"},
+				Right: &ast.Integer{Value: "-1"},
+			},
+			[]any{[]any{"b", "c"}},
+		},
+		{&ast.Span{Left: &ast.Integer{Value: "-9"}}, []any{[]any{"a", "b", "c", "d"}}},
+		{&ast.Span{Left: &ast.Integer{Value: "9"}}, []any{[]any{}}},
+		{
+			&ast.Span{
+				Left:  &ast.Integer{Value: "3"},
+				Right: &ast.Integer{Value: "1"},
+			},
+			[]any{[]any{}},
+		},
+		{&ast.Span{Step: &ast.Integer{Value: "2"}}, []any{[]any{"a", "c"}}},
+		{&ast.Span{Step: &ast.Integer{Value: "-1"}}, []any{[]any{"d", "c", "b", "a"}}},
+		{&ast.Span{Step: &ast.Integer{Value: "-2"}}, []any{[]any{"d", "b"}}},
+		{
+			&ast.Span{
+				Left: &ast.Integer{Value: "1"},
+				Step: &ast.Integer{Value: "2"},
+			},
+			[]any{[]any{"b", "d"}},
+		},
+		{
+			&ast.Span{
+				Left:  &ast.Integer{Value: "-2"},
+				Right: &ast.Integer{Value: "0"},
+				Step:  &ast.Integer{Value: "-1"},
+			},
+			[]any{[]any{"c", "b"}},
+		},
+		{
+			&ast.Span{
+				Right: &ast.Integer{Value: "-9"},
+				Step:  &ast.Integer{Value: "-1"},
+			},
+			[]any{[]any{"d", "c", "b", "a"}},
+		},
+		{
+			&ast.Span{
+				Left: &ast.Integer{Value: "0"},
+				Step: &ast.Integer{Value: "-1"},
+			},
+			[]any{[]any{"a"}},
+		},
+		{
+			&ast.Span{
+				Left:  &ast.Integer{Value: "1"},
+				Right: &ast.Integer{Value: "3"},
+				Step:  &ast.Integer{Value: "-1"},
+			},
+			[]any{[]any{}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.span.String(), func(t *testing.T) {
+			i := New()
+			i.VisitSpan(c.span)
+			have, err := i.filters[0].call(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Check if index and span filters select runes rather than bytes of strings.
+func TestVisitStringData(t *testing.T) {
+	cases := []struct {
+		name string
+		node ast.Expr
+		data string
+		want []any
+	}{
+		{"index", &ast.Integer{Value: "1"}, "żółw", []any{"ó"}},
+		{"negative-index", &ast.Integer{Value: "-1"}, "żółw", []any{"w"}},
+		{"index-out-of-range", &ast.Integer{Value: "4"}, "żółw", []any{}},
+		{
+			"span",
+			&ast.Span{Right: &ast.Integer{Value: "7"}},
+			"9fceb02d0ae598e95dc970b74767f19372d61af8",
+			[]any{"9fceb02"},
+		},
+		{
+			"span-runes",
+			&ast.Span{Left: &ast.Integer{Value: "1"}, Right: &ast.Integer{Value: "3"}},
+			"żółw",
+			[]any{"ół"},
+		},
+		{"span-reversed", &ast.Span{Step: &ast.Integer{Value: "-1"}}, "żółw", []any{"włóż"}},
+		{"span-empty", &ast.Span{Left: &ast.Integer{Value: "9"}}, "żółw", []any{""}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			i := New()
+			i.eval(c.node)
+			have, err := i.filters[0].call(c.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
 // Check if filter function errors out when provided unsupported data input.
 func TestVisitError(t *testing.T) {
 	var data interface{}
@@ -211,7 +619,7 @@ func TestVisitError(t *testing.T) {
 			defer func() { i.filters = nil }()
 			c.fn(c.node)
 			filter := i.filters[0]
-			_, err := filter.inner(data)
+			_, err := filter.call(data)
 			if err == nil {
 				t.Errorf("filter function should error with data: %v", data)
 			}
@@ -232,9 +640,707 @@ func TestInterpretError(t *testing.T) {
 		},
 	}
 	i := Interpreter{}
-	exec := i.Interpret(root)
-	_, err := exec(data)
+	exec, err := i.Interpret(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = exec(data)
 	if err == nil {
 		t.Errorf("Interpret should fail with data: %v", data)
 	}
 }
+
+// Test comparison and boolean operators against TOML data.
+func TestOperators(t *testing.T) {
+	data := map[string]any{
+		"port":    int64(8080),
+		"ratio":   0.5,
+		"role":    "backend",
+		"enabled": true,
+		"tags":    []any{"a", "b"},
+		"date":    toml.LocalDate{Year: 1979, Month: 5, Day: 27},
+		"since":   time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`.role == "backend"`, []any{true}},
+		{`.role != "backend"`, []any{false}},
+		{`.port == 8080`, []any{true}},
+		{`.port > 1024`, []any{true}},
+		{`.port <= 1024`, []any{false}},
+		{`.ratio < 1`, []any{true}},
+		{`.ratio >= .port`, []any{false}},
+		{`.tags == .tags`, []any{true}},
+		{`.role == 1`, []any{false}},
+		{`.date == .date`, []any{true}},
+		{`.date == .since`, []any{false}},
+		{`.port > 1024 and .role == "backend"`, []any{true}},
+		{`.port < 1024 and .missing`, []any{false}},
+		{`.port < 1024 or .enabled`, []any{true}},
+		{`.enabled or .missing`, []any{true}},
+		{`.enabled and .role`, []any{true}},
+		{`.enabled | not`, []any{false}},
+		{`.port | not`, []any{false}},
+		{`.port == 1 or .port == 2 or .port == 8080`, []any{true}},
+		{`.tags[] == "a"`, []any{true, false}},
+		{`.tags[] and .enabled`, []any{true, true}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if ordering values that cannot be compared results in an error.
+func TestOperatorsError(t *testing.T) {
+	data := map[string]any{"role": "backend", "port": int64(8080)}
+	_, err := run(t, ".role < .port", data)
+	if !errors.Is(err, ErrTOMLDataType) {
+		t.Errorf("have: %v; want: %v", err, ErrTOMLDataType)
+	}
+	want := "Interpreter error: cannot apply ( binary < ) to " +
+		"[ string ] ( backend ) and [ int64 ] ( 8080 ): wrong type error"
+	if have := err.Error(); have != want {
+		t.Errorf("have: %s; want: %s", have, want)
+	}
+}
+
+// Verify if undefined names are reported without the queried data, even
+// where no data reaches them.
+func TestUndefinedError(t *testing.T) {
+	data := map[string]any{"role": "backend", "port": int64(8080)}
+	cases := []struct {
+		query string
+		err   error
+		want  string
+	}{
+		{
+			query: "foo(1)",
+			err:   ErrFunctionUndefined,
+			want: "Interpreter error: cannot evaluate ( call foo/1 ): " +
+				"undefined function",
+		},
+		{
+			query: "$x",
+			err:   ErrVariableUndefined,
+			want: "Interpreter error: cannot evaluate ( $x ): " +
+				"undefined variable",
+		},
+		{
+			query: "@foo",
+			err:   ErrFormatUndefined,
+			want: "Interpreter error: cannot evaluate ( format @foo ): " +
+				"undefined format",
+		},
+		{
+			query: ".missing[]? | lenght",
+			err:   ErrFunctionUndefined,
+			want: "Interpreter error: cannot evaluate ( call lenght/0 ): " +
+				"undefined function",
+		},
+		{
+			query: "select(false) | $undefind",
+			err:   ErrVariableUndefined,
+			want: "Interpreter error: cannot evaluate ( $undefind ): " +
+				"undefined variable",
+		},
+		{
+			query: `.missing[]? | @jsn "\(.)"`,
+			err:   ErrFormatUndefined,
+			want: "Interpreter error: cannot evaluate ( @jsn ): " +
+				"undefined format",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			_, err := run(t, c.query, data)
+			if !errors.Is(err, c.err) {
+				t.Errorf("have: %v; want: %v", err, c.err)
+			}
+			if have := err.Error(); have != c.want {
+				t.Errorf("have: %s; want: %s", have, c.want)
+			}
+		})
+	}
+}
+
+// Check if select passes through the input data for true conditions only.
+func TestSelect(t *testing.T) {
+	data := map[string]any{
+		"servers": map[string]any{
+			"alpha": map[string]any{"ip": "10.0.0.1", "role": "frontend"},
+			"beta":  map[string]any{"ip": "10.0.0.2", "role": "backend"},
+			"gamma": map[string]any{"ip": "10.0.0.3", "role": "backend"},
+		},
+		"ports": []any{int64(80), int64(443), int64(8080)},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`.servers[] | select(.role == "backend") | .ip`, []any{"10.0.0.2", "10.0.0.3"}},
+		{`.servers[] | select(.role == "database") | .ip`, []any{}},
+		{`.ports[] | select(. > 100 and . < 1000)`, []any{int64(443)}},
+		{`.servers[] | select(.missing)`, []any{}},
+		{`.ports[] | select(. == 80, . == 80)`, []any{int64(80), int64(80)}},
+		{`.ports | select(.[0] == 80) | .[-1]`, []any{int64(8080)}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Test arithmetic operators in queries run against TOML data.
+func TestInterpretArithmetic(t *testing.T) {
+	data := map[string]any{
+		"limits": map[string]any{"max_conns": int64(100), "ratio": 0.25},
+		"paths":  map[string]any{"root": "/srv", "name": "app"},
+		"ports":  []any{int64(80), int64(443)},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`.limits.max_conns * 2`, []any{int64(200)}},
+		{`.limits.max_conns -1`, []any{int64(99)}},
+		{`.limits.max_conns - -1`, []any{int64(101)}},
+		{`1 + 2 * 3`, []any{int64(7)}},
+		{`(1 + 2) * 3`, []any{int64(9)}},
+		{`10 - 4 - 3`, []any{int64(3)}},
+		{`.limits.max_conns * .limits.ratio`, []any{25.0}},
+		{`.limits.max_conns / 8 > 12`, []any{true}},
+		{`.paths.root + "/" + .paths.name`, []any{"/srv/app"}},
+		{`.ports + .ports`, []any{[]any{int64(80), int64(443), int64(80), int64(443)}}},
+		{`.ports[] % 2 == 0`, []any{true, false}},
+		{`(.ports[0], .ports[1]) + 1`, []any{int64(81), int64(444)}},
+		{`-.limits.max_conns`, []any{int64(-100)}},
+		{`.limits.max_conns * -.limits.ratio`, []any{-25.0}},
+		{`-(.ports[] + 1)`, []any{int64(-81), int64(-444)}},
+		{`- -.limits.ratio`, []any{0.25}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Check if literals compare equal to the values decoded from the TOML input.
+func TestInterpretLiterals(t *testing.T) {
+	doc := `
+odt = 1979-05-27T07:32:00-08:00
+ldt = 1979-05-27T07:32:00
+ld = 1979-05-27
+ratio = 0.5
+big = inf
+enabled = true
+`
+	var data map[string]any
+	if err := toml.Unmarshal([]byte(doc), &data); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`.odt == 1979-05-27T15:32:00Z`, []any{true}},
+		{`.odt < 1979-05-27T07:32:00Z`, []any{false}},
+		{`.ldt == 1979-05-27T07:32:00`, []any{true}},
+		{`.ld == 1979-05-27`, []any{true}},
+		{`.ld == 1979-05-27T00:00:00`, []any{false}},
+		{`.ratio == 0.5`, []any{true}},
+		{`.ratio * 1e2`, []any{50.0}},
+		{`.big == inf`, []any{true}},
+		{`.big > -inf`, []any{true}},
+		{`nan == nan`, []any{false}},
+		{`nan != nan`, []any{true}},
+		{`[nan] == [nan]`, []any{false}},
+		{`nan < 0`, []any{true}},
+		{`[nan, 1, nan] | sort | length`, []any{int64(3)}},
+		{`.enabled == true`, []any{true}},
+		{`false or .enabled`, []any{true}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Test the construction of tables from the entries run against TOML data.
+func TestInterpretObject(t *testing.T) {
+	data := map[string]any{
+		"servers": []any{
+			map[string]any{"hostname": "alpha", "ip": "10.0.0.1"},
+			map[string]any{"hostname": "beta", "ip": "10.0.0.2"},
+		},
+		"key": "port",
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{
+			`.servers[] | {name: .hostname, addr: .ip}`,
+			[]any{
+				map[string]any{"name": "alpha", "addr": "10.0.0.1"},
+				map[string]any{"name": "beta", "addr": "10.0.0.2"},
+			},
+		},
+		{
+			`.servers[0] | {ip, "hostname"}`,
+			[]any{map[string]any{"ip": "10.0.0.1", "hostname": "alpha"}},
+		},
+		{
+			`{(.key): 8080, (.key + "s"): 80}`,
+			[]any{map[string]any{"port": int64(8080), "ports": int64(80)}},
+		},
+		{
+			`{a: (1, 2), b: (3, 4)}`,
+			[]any{
+				map[string]any{"a": int64(1), "b": int64(3)},
+				map[string]any{"a": int64(1), "b": int64(4)},
+				map[string]any{"a": int64(2), "b": int64(3)},
+				map[string]any{"a": int64(2), "b": int64(4)},
+			},
+		},
+		{
+			`{(.servers[].hostname): true}`,
+			[]any{
+				map[string]any{"alpha": true},
+				map[string]any{"beta": true},
+			},
+		},
+		{`{a: .missing}`, []any{}},
+		{`{}`, []any{map[string]any{}}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if keys of constructed tables other than strings result in an error.
+func TestInterpretObjectError(t *testing.T) {
+	_, err := run(t, "{(1): true}", map[string]any{})
+	if !errors.Is(err, ErrTOMLDataType) {
+		t.Errorf("have: %v; want: %v", err, ErrTOMLDataType)
+	}
+}
+
+// Check if the array construction collects all outputs into a single array.
+func TestInterpretArray(t *testing.T) {
+	data := map[string]any{
+		"servers": map[string]any{
+			"alpha": map[string]any{"ip": "10.0.0.1"},
+			"beta":  map[string]any{"ip": "10.0.0.2"},
+		},
+		"ports": []any{int64(80), int64(443)},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`[.servers[].ip]`, []any{[]any{"10.0.0.1", "10.0.0.2"}}},
+		{`[.ports[] | . + 1]`, []any{[]any{int64(81), int64(444)}}},
+		{`[.ports[] | select(. > 1000)]`, []any{[]any{}}},
+		{`[1, "a", true][1]`, []any{"a"}},
+		{`.ports + [(8080)]`, []any{[]any{int64(80), int64(443), int64(8080)}}},
+		{`{ports: [.ports[0]]}`, []any{map[string]any{"ports": []any{int64(80)}}}},
+		{`[.servers[] | [.ip]]`, []any{[]any{[]any{"10.0.0.1"}, []any{"10.0.0.2"}}}},
+		{`.ports | [0]`, []any{[]any{int64(0)}}},
+		{`.ports | .[0]`, []any{int64(80)}},
+		{`.["ports"][-1:]`, []any{[]any{int64(443)}}},
+		{`["ports"][-1:]`, []any{[]any{"ports"}}},
+		{`[1] | length`, []any{int64(1)}},
+		{`[97] | implode`, []any{"a"}},
+		{`[1] as [$a] | $a`, []any{int64(1)}},
+		{`. | []`, []any{[]any{}}},
+		{`. | [1] | length`, []any{int64(1)}},
+		{`[[1]]`, []any{[]any{[]any{int64(1)}}}},
+		{`.ports, [[1], []]`, []any{
+			[]any{int64(80), int64(443)},
+			[]any{[]any{int64(1)}, []any{}},
+		}},
+		{`[[1, [2]], 3] | flatten`, []any{[]any{int64(1), int64(2), int64(3)}}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Check if interpolated expressions are converted to strings and joined with
+// the rest of the quoted string for every combination of their outputs.
+func TestInterpretInterpolation(t *testing.T) {
+	data := map[string]any{
+		"db": map[string]any{
+			"host":  "db.local",
+			"port":  int64(5432),
+			"ratio": 0.5,
+			"ssl":   true,
+			"tags":  []any{"a", "b"},
+			"since": toml.LocalDate{Year: 1979, Month: 5, Day: 27},
+		},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`.db | "\(.host):\(.port)"`, []any{"db.local:5432"}},
+		{`.db | "postgres://\(.host)/?ssl=\(.ssl)&r=\(.ratio)"`, []any{"postgres://db.local/?ssl=true&r=0.5"}},
+		{`.db | "\(.tags) \(.since)"`, []any{`["a","b"] 1979-05-27`}},
+		{`"\(inf) \(-inf) \(nan)"`, []any{"inf -inf nan"}},
+		{`.db | "\(.tags[])-\(1, 2)"`, []any{"a-1", "a-2", "b-1", "b-2"}},
+		{`.db | "\("\(.port + 1)" | length)"`, []any{"4"}},
+		{`.db | "\(.missing)"`, []any{}},
+		{`"\("")"`, []any{""}},
+		{`'\('a') \t'`, []any{"a \t"}},
+		{`.db | {"\(.host)-port": .port}`, []any{map[string]any{"db.local-port": int64(5432)}}},
+		{`.db | "(\(.port))" | length`, []any{int64(6)}},
+		{`"\\(" + "x"`, []any{`\(x`}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Check if bound variables refer to outputs of the binding source in the body
+// of the binding and nowhere else.
+func TestInterpretVariables(t *testing.T) {
+	data := map[string]any{
+		"owner": map[string]any{"name": "ops"},
+		"servers": []any{
+			map[string]any{"ip": "10.0.0.1"},
+			map[string]any{"ip": "10.0.0.2"},
+		},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{
+			`.owner.name as $o | .servers[] | {server: .ip, owner: $o}`,
+			[]any{
+				map[string]any{"server": "10.0.0.1", "owner": "ops"},
+				map[string]any{"server": "10.0.0.2", "owner": "ops"},
+			},
+		},
+		{`(1, 2) as $x | $x * 10`, []any{int64(10), int64(20)}},
+		{`1, 2 as $x | $x * 10`, []any{int64(1), int64(20)}},
+		{`2 * 3 as $x | $x + 1`, []any{int64(8)}},
+		{`1 as $x | (2 as $x | $x), $x`, []any{int64(2), int64(1)}},
+		{`1 as $x | 2 as $y | [$x, $y]`, []any{[]any{int64(1), int64(2)}}},
+		{`.owner.name as $name | {$name}`, []any{map[string]any{"name": "ops"}}},
+		{`[.servers[].ip as $ip | $ip]`, []any{[]any{"10.0.0.1", "10.0.0.2"}}},
+		{`.servers[0].ip as $ip | .owner | "\($ip) by \(.name)"`, []any{"10.0.0.1 by ops"}},
+		{`.missing as $x | $x`, []any{}},
+		{`[(1, 2) as $x | (3, 4) as $y | $x + $y]`, []any{[]any{int64(4), int64(5), int64(5), int64(6)}}},
+		{`$__loc__`, []any{map[string]any{"file": "<query>", "line": int64(1)}}},
+		{"1 |\n$__loc__.line", []any{int64(2)}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if references to variables that are not in scope result in an error.
+func TestInterpretVariablesError(t *testing.T) {
+	cases := []string{
+		`$x`,
+		`(1 as $x | $x) | $x`,
+		`[1 as $x | $x] | $x`,
+	}
+	for _, query := range cases {
+		t.Run(query, func(t *testing.T) {
+			_, err := run(t, query, map[string]any{})
+			if !errors.Is(err, ErrVariableUndefined) {
+				t.Errorf("have: %v; want: %v", err, ErrVariableUndefined)
+			}
+		})
+	}
+}
+
+// Check if patterns destructure arrays and tables into variables, and if
+// alternative patterns are tried in turn for every output of the source.
+func TestInterpretPatterns(t *testing.T) {
+	data := map[string]any{
+		"db": map[string]any{
+			"host":  "db.local",
+			"ports": []any{int64(5432), int64(5433)},
+		},
+		"entries": []any{
+			map[string]any{"name": "alpha", "addr": "10.0.0.1"},
+			[]any{"beta", "10.0.0.2"},
+			"gamma",
+		},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`.db as {host: $h, ports: [$first]} | "\($h):\($first)"`, []any{"db.local:5432"}},
+		{`.db as {$host, $ports: [$a, $b]} | [$host, $a, $b, $ports]`, []any{[]any{"db.local", int64(5432), int64(5433), []any{int64(5432), int64(5433)}}}},
+		{`.db as {"host": $h, ("po" + "rts"): [$_, $p]} | [$h, $p]`, []any{[]any{"db.local", int64(5433)}}},
+		{`.db.ports as [$a, $b] | $b - $a`, []any{int64(1)}},
+		{`.db as {(.host, "host"): $h} | $h`, []any{"db.local"}},
+		{`.db as {("host", "host"): $h} | $h`, []any{"db.local", "db.local"}},
+		{`.db as {"\(.host | .[:2])": $h} | $h`, []any{}},
+		{`.db as {missing: $m} | 1`, []any{}},
+		{`.db.ports as [$a, $b, $c] | 1`, []any{}},
+		{`.db.ports as [$a, $a] | $a`, []any{int64(5433)}},
+		{`.entries[] as {$name, $addr} ?// [$name, $addr] ?// $name | [$name, $addr]`, []any{
+			[]any{"alpha", "10.0.0.1"},
+			[]any{"beta", "10.0.0.2"},
+			[]any{"gamma"},
+		}},
+		{`.entries[:2][] as [$x] ?// {name: $x} | $x`, []any{"alpha", "beta"}},
+		{`.entries[] as [$a] ?// $a | $a | length`, []any{int64(2), int64(4), int64(5)}},
+		{`.db as {ports: [$p]} ?// {host: $p} | $p`, []any{int64(5432)}},
+		{`.db as {absent: $p} ?// {host: $p} | $p`, []any{"db.local"}},
+		{`.entries[0] as $e ?// [$e] | ($e | .name + "!")`, []any{"alpha!"}},
+		{`.entries[1] as $e ?// [$e] | ($e | ascii_upcase)`, []any{"BETA"}},
+		{`[[3], 4] | .[] as [$a] ?// $a | $a`, []any{int64(3), int64(4)}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if patterns that do not fit the data result in an error unless an
+// alternative pattern matches.
+func TestInterpretPatternsError(t *testing.T) {
+	data := map[string]any{"ports": []any{int64(80)}, "host": "a"}
+	cases := []struct {
+		query string
+		want  error
+	}{
+		{`. as [$a] | $a`, ErrTOMLDataType},
+		{`.ports as {$a} | $a`, ErrTOMLDataType},
+		{`. as {(1): $a} | $a`, ErrTOMLDataType},
+		{`. as [$a] ?// {host: [$a]} | $a`, ErrTOMLDataType},
+		{`. as $a ?// [$a] | $a | .[0]`, ErrTOMLDataType},
+		{`. as {$host} | $host | .x`, ErrTOMLDataType},
+		{`. as [$a] ?// {$host} | $a`, nil},
+		{`.host as [$a] ?// {name: $a} | $a`, ErrTOMLDataType},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			_, err := run(t, c.query, data)
+			if !errors.Is(err, c.want) {
+				t.Errorf("have: %v; want: %v", err, c.want)
+			}
+		})
+	}
+}
+
+// Check if functions defined in the query take filter and value arguments,
+// close over the scope of their definition, recurse and shadow builtins.
+func TestInterpretDefinitions(t *testing.T) {
+	data := map[string]any{
+		"servers": []any{
+			map[string]any{"ip": "10.0.0.1", "weight": int64(2)},
+			map[string]any{"ip": "10.0.0.2", "weight": int64(3)},
+		},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`def ips: .servers[].ip; [(ips)]`, []any{[]any{"10.0.0.1", "10.0.0.2"}}},
+		{`def twice(f): f | f; 3 | twice(. * 2)`, []any{int64(12)}},
+		{`def both(f): [(f)]; both(1, 2)`, []any{[]any{int64(1), int64(2)}}},
+		{`def add($a; $b): $a + $b; add(1, 2; 10)`, []any{int64(11), int64(12)}},
+		{`def f($a): a + $a; f(1)`, []any{int64(2)}},
+		{`def weights(f): [.servers[] | f]; weights(.weight * 10)`, []any{[]any{int64(20), int64(30)}}},
+		{`def f(g): 1 as $x | g; 2 as $x | f($x)`, []any{int64(2)}},
+		{`1 as $x | def f: $x; 2 as $x | f`, []any{int64(1)}},
+		{`def f($x): def g: $x * 10; g; f(1), f(2)`, []any{int64(10), int64(20)}},
+		{`def f: 1; def g: f + 1; def f: 10; g, f`, []any{int64(2), int64(10)}},
+		{`def length: "mine"; length`, []any{"mine"}},
+		{`def length(f): f; "ab" | length, length(0)`, []any{int64(2), int64(0)}},
+		{`def map(f): "mine"; [(1)] | map(. + 1)`, []any{"mine"}},
+		{`def count($n): $n, (select($n < 3) | count($n + 1)); [count(0)]`, []any{[]any{int64(0), int64(1), int64(2), int64(3)}}},
+		{`def fact($n): (select($n <= 1) | 1), (select($n > 1) | $n * fact($n - 1)); fact(5)`, []any{int64(120)}},
+		{`def rec(f; $n): f, (select($n > 0) | rec(f + 1; $n - 1)); 0 | [rec(.; 2)]`, []any{[]any{int64(0), int64(1), int64(2)}}},
+		{`def f(g): def h: g; h; f(5)`, []any{int64(5)}},
+		{`[(1, 2) | def inc: . + 1; inc]`, []any{[]any{int64(2), int64(3)}}},
+		{`def f: def g: 3; g * 2; f`, []any{int64(6)}},
+		{`def nat: 0, (nat | . + 1); [limit(4; nat)]`, []any{[]any{int64(0), int64(1), int64(2), int64(3)}}},
+		{`def f($a): $a; f(.servers[].ip)`, []any{"10.0.0.1", "10.0.0.2"}},
+		{`.servers[0] as {$ip} | def f: $ip; .servers[1] | f, .ip`, []any{"10.0.0.1", "10.0.0.2"}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if functions are not visible outside of the scope of their
+// definition.
+func TestInterpretDefinitionsError(t *testing.T) {
+	cases := []struct {
+		query string
+		want  error
+	}{
+		{`(def f(g): g; f(1)), f(1)`, ErrFunctionUndefined},
+		{`def f(g): g; f(1; 2)`, ErrFunctionUndefined},
+		{`def f: $x; 1 as $x | f`, ErrVariableUndefined},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			_, err := run(t, c.query, map[string]any{})
+			if !errors.Is(err, c.want) {
+				t.Errorf("have: %v; want: %v", err, c.want)
+			}
+		})
+	}
+}
+
+// Check if reductions fold outputs of the source into the state, and if
+// every output of the update expression becomes the state in turn.
+func TestInterpretReductions(t *testing.T) {
+	data := map[string]any{
+		"backend": []any{
+			map[string]any{"name": "alpha", "weight": int64(2)},
+			map[string]any{"name": "beta", "weight": int64(3)},
+		},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`reduce .backend[] as $b (0; . + $b.weight)`, []any{int64(5)}},
+		{`reduce .backend[] as $b ([]; . + [$b.name])`, []any{[]any{"alpha", "beta"}}},
+		{`reduce .backend[] as {$name, $weight} ({}; . + {($name): $weight})`, []any{map[string]any{"alpha": int64(2), "beta": int64(3)}}},
+		{`reduce range(5) as $x (0, 100; . + $x)`, []any{int64(10), int64(110)}},
+		{`reduce range(3) as $x (0; . + 1, . + 10)`, []any{int64(30)}},
+		{`reduce range(3) as $x (0; select($x != 1) | . + $x)`, []any{int64(2)}},
+		{`reduce .missing[]? as $x (7; . + $x)`, []any{int64(7)}},
+		{`reduce (1, [2], 3) as [$x] ?// $x (0; . + $x)`, []any{int64(6)}},
+		{`[foreach range(1; 4) as $x (0; . + $x)]`, []any{[]any{int64(1), int64(3), int64(6)}}},
+		{`[foreach range(1; 4) as $x (0; . + $x; [$x, .])]`, []any{[]any{
+			[]any{int64(1), int64(1)},
+			[]any{int64(2), int64(3)},
+			[]any{int64(3), int64(6)},
+		}}},
+		{`[foreach range(3) as $x ([]; . + [$x])]`, []any{[]any{
+			[]any{int64(0)},
+			[]any{int64(0), int64(1)},
+			[]any{int64(0), int64(1), int64(2)},
+		}}},
+		{`[foreach (1, 2) as $x (0; . + $x, . - $x)]`, []any{[]any{int64(1), int64(-1), int64(1), int64(-3)}}},
+		{`[foreach range(4) as $x (0; . + $x; select(. > 1))]`, []any{[]any{int64(3), int64(6)}}},
+		{`[foreach .backend[] as {$weight} (0; . + $weight; ., . * 10)]`, []any{[]any{int64(2), int64(20), int64(5), int64(50)}}},
+		{`[limit(2; foreach range(1; 9223372036854775807) as $x (0; . + $x))]`, []any{[]any{int64(1), int64(3)}}},
+		{`def sum(f): reduce f as $x (0; . + $x); sum(.backend[].weight)`, []any{int64(5)}},
+		{`1 as $x | reduce (2, 3) as $x (0; . + $x) | . + $x`, []any{int64(6)}},
+		{`reduce .backend[] as $b (0; . + $b.weight) as $total | .backend[] | .weight / $total`, []any{0.4, 0.6}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if errors raised while reducing stop the reduction.
+func TestInterpretReductionsError(t *testing.T) {
+	cases := []struct {
+		query string
+		want  error
+	}{
+		{`reduce (1, "a") as $x (0; . + $x)`, ErrTOMLDataType},
+		{`[foreach (1, 0) as $x (0; 1 / $x)]`, ErrDivisionByZero},
+		{`reduce 1 as [$x] (0; .)`, ErrTOMLDataType},
+		{`reduce 1 as $x (0; .) | $x`, ErrVariableUndefined},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			_, err := run(t, c.query, map[string]any{})
+			if !errors.Is(err, c.want) {
+				t.Errorf("have: %v; want: %v", err, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package interpreter
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// pattern is a compiled regular expression with the flags that do not change
+// the expression itself but the way its matches are searched for.
+type pattern struct {
+	re       *regexp.Regexp
+	global   bool
+	nonEmpty bool
+}
+
+// compilePattern compiles the regular expression expr with flags. The flags
+// g, n and l search for all matches, ignore empty matches and prefer the
+// leftmost-longest match. The flags i, m, s and U map onto the inline flags of
+// the Go regular expression syntax.
+func compilePattern(expr, flags, name string) (*pattern, error) {
+	p := pattern{}
+	longest := false
+	var inline strings.Builder
+	for _, f := range flags {
+		switch f {
+		case 'g':
+			p.global = true
+		case 'n':
+			p.nonEmpty = true
+		case 'l':
+			longest = true
+		case 'i', 'm', 's', 'U':
+			inline.WriteRune(f)
+		default:
+			return nil, &Error{data: flags, filter: name, err: ErrArgumentValue}
+		}
+	}
+	if inline.Len() > 0 {
+		expr = "(?" + inline.String() + ")" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, &Error{data: expr, filter: name, err: ErrRegexp}
+	}
+	if longest {
+		re.Longest()
+	}
+	p.re = re
+	return &p, nil
+}
+
+// matches returns the positions of the matches of the pattern and of its
+// groups in the string s as in regexp.FindAllStringSubmatchIndex. Without the
+// global flag, the search stops at the first match.
+func (p *pattern) matches(s string) [][]int {
+	switch {
+	case p.global:
+		result := p.re.FindAllStringSubmatchIndex(s, -1)
+		if p.nonEmpty {
+			result = dropEmpty(result)
+		}
+		return result
+	case !p.nonEmpty:
+		if m := p.re.FindStringSubmatchIndex(s); m != nil {
+			return [][]int{m}
+		}
+		return nil
+	}
+	// NOTE: Matches after an empty one depend on where the empty one ends, so
+	// the search for the first non-empty match asks for twice as many matches
+	// every time instead of searching again from an offset.
+	for n := 1; ; n *= 2 {
+		ms := p.re.FindAllStringSubmatchIndex(s, n)
+		found := len(ms)
+		if ms = dropEmpty(ms); len(ms) > 0 {
+			return ms[:1]
+		}
+		if found < n {
+			return nil
+		}
+	}
+}
+
+// dropEmpty leaves out empty matches from the result of
+// regexp.FindAllStringSubmatchIndex.
+func dropEmpty(ms [][]int) [][]int {
+	result := ms[:0]
+	for _, m := range ms {
+		if m[0] != m[1] {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// patterns compiles every combination of outputs of the first argument and
+// the argument at the position of flags, and it passes each pattern along with
+// the input string to fn. Flags default to none without the argument, and
+// the global flag is always set for global builtins.
+func patterns(
+	data any,
+	args []stream,
+	flags int,
+	name string,
+	global bool,
+	fn func(str string, p *pattern) error,
+) error {
+	str, ok := data.(string)
+	if !ok {
+		return typeError(data, name)
+	}
+	fs := literal("")
+	if flags < len(args) {
+		fs = args[flags]
+	}
+	return texts(args[0], data, name, func(expr string) error {
+		return texts(fs, data, name, func(f string) error {
+			p, err := compilePattern(expr, f, name)
+			if err != nil {
+				return err
+			}
+			p.global = p.global || global
+			return fn(str, p)
+		})
+	})
+}
+
+// test reports if the string matches the regular expression.
+func test(data any, args []stream, emit emitter) error {
+	return patterns(data, args, 1, "test", false,
+		func(str string, p *pattern) error {
+			return emit(len(p.matches(str)) > 0)
+		})
+}
+
+// match returns the table describing the first match of the regular
+// expression in the string or every match with the global flag. The table
+// holds the offset, the length and the string of the match along with the
+// array of captures describing its groups the same way. Captures of named
+// groups have names, and captures of groups that did not take part in the
+// match have the offset of -1 and no string.
+func match(data any, args []stream, emit emitter) error {
+	return patterns(data, args, 1, "match", false,
+		func(str string, p *pattern) error {
+			names := p.re.SubexpNames()
+			for _, m := range p.matches(str) {
+				captures := make([]any, 0, len(names)-1)
+				for g := 1; g < len(names); g++ {
+					c := matched(str, m[2*g], m[2*g+1])
+					if names[g] != "" {
+						c["name"] = names[g]
+					}
+					captures = append(captures, c)
+				}
+				result := matched(str, m[0], m[1])
+				result["captures"] = captures
+				if err := emit(result); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+// matched returns the table describing the part of the string s between byte
+// offsets from and to. Offsets in the table count characters.
+func matched(s string, from, to int) map[string]any {
+	if from < 0 {
+		return map[string]any{"offset": int64(-1), "length": int64(0)}
+	}
+	return map[string]any{
+		"offset": int64(utf8.RuneCountInString(s[:from])),
+		"length": int64(utf8.RuneCountInString(s[from:to])),
+		"string": s[from:to],
+	}
+}
+
+// capture returns the table of strings captured by named groups of the first
+// match of the regular expression or of every match with the global flag.
+// Groups that did not take part in the match are left out.
+func capture(data any, args []stream, emit emitter) error {
+	return patterns(data, args, 1, "capture", false,
+		func(str string, p *pattern) error {
+			for _, m := range p.matches(str) {
+				if err := emit(captured(p, str, m)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+// captured returns the table of strings captured by named groups of the match.
+func captured(p *pattern, s string, m []int) map[string]any {
+	result := map[string]any{}
+	for g, name := range p.re.SubexpNames() {
+		if g > 0 && name != "" && m[2*g] >= 0 {
+			result[name] = s[m[2*g]:m[2*g+1]]
+		}
+	}
+	return result
+}
+
+// scan returns every match of the regular expression in the string. For
+// expressions with groups, it returns the array of captured strings instead,
+// and groups that did not take part in the match capture the empty string.
+func scan(data any, args []stream, emit emitter) error {
+	return patterns(data, args, 1, "scan", true,
+		func(str string, p *pattern) error {
+			for _, m := range p.matches(str) {
+				var result any = str[m[0]:m[1]]
+				if len(m) > 2 {
+					groups := make([]any, 0, len(m)/2-1)
+					for g := 2; g < len(m); g += 2 {
+						if m[g] < 0 {
+							groups = append(groups, "")
+							continue
+						}
+						groups = append(groups, str[m[g]:m[g+1]])
+					}
+					result = groups
+				}
+				if err := emit(result); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+// splitRegexp splits the string on every match of the regular expression into
+// the array of strings.
+func splitRegexp(data any, args []stream, emit emitter) error {
+	return patterns(data, args, 1, "split", true,
+		func(str string, p *pattern) error {
+			return emit(parts(str, p))
+		})
+}
+
+// splits returns the parts of the string separated by matches of the regular
+// expression one by one.
+func splits(data any, args []stream, emit emitter) error {
+	return patterns(data, args, 1, "splits", true,
+		func(str string, p *pattern) error {
+			for _, s := range parts(str, p) {
+				if err := emit(s); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+// parts returns the parts of the string s separated by matches of the pattern.
+func parts(s string, p *pattern) []any {
+	result := []any{}
+	last := 0
+	for _, m := range p.matches(s) {
+		result = append(result, s[last:m[0]])
+		last = m[1]
+	}
+	return append(result, s[last:])
+}
+
+// sub replaces the first match of the regular expression in the string or
+// every match with the global flag. The replacement is the second argument run
+// against the table of strings captured by named groups of the match, so that
+// it can refer to them, and it has to produce strings. Every combination of
+// outputs of the replacement for every match gives another output.
+func sub(data any, args []stream, emit emitter) error {
+	return substitute(data, args, "sub", false, emit)
+}
+
+// gsub replaces every match of the regular expression in the string as sub
+// does with the global flag.
+func gsub(data any, args []stream, emit emitter) error {
+	return substitute(data, args, "gsub", true, emit)
+}
+
+func substitute(
+	data any,
+	args []stream,
+	name string,
+	global bool,
+	emit emitter,
+) error {
+	return patterns(data, args, 2, name, global,
+		func(str string, p *pattern) error {
+			ms := p.matches(str)
+			var replace func(n, last int, acc string) error
+			replace = func(n, last int, acc string) error {
+				if n == len(ms) {
+					return emit(acc + str[last:])
+				}
+				m := ms[n]
+				return texts(args[1], captured(p, str, m), name, func(r string) error {
+					return replace(n+1, m[1], acc+str[last:m[0]]+r)
+				})
+			}
+			return replace(0, 0, "")
+		})
+}
@@ -0,0 +1,115 @@
+package interpreter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Test regular expression builtins on strings found in TOML data.
+func TestRegexp(t *testing.T) {
+	data := map[string]any{
+		"image": "registry.local/web:1.4.2",
+		"dsn":   "postgres://admin@db:5432/app",
+		"city":  "Kraków, Łódź",
+		"hosts": "alpha, beta,gamma",
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`.image | test(":[0-9.]+$")`, []any{true}},
+		{`.image | test("WEB")`, []any{false}},
+		{`.image | test("WEB"; "i")`, []any{true}},
+		{`.image | test("web", "db")`, []any{true, false}},
+		{
+			`.city | match("ó")`,
+			[]any{
+				map[string]any{
+					"offset":   int64(4),
+					"length":   int64(1),
+					"string":   "ó",
+					"captures": []any{},
+				},
+			},
+		},
+		{`.city | match("[ół]"; "gi") | .offset`, []any{int64(4), int64(8), int64(9)}},
+		{
+			`.image | match("(?P<name>[a-z]+):(\\d+)?(x)?")`,
+			[]any{
+				map[string]any{
+					"offset": int64(15),
+					"length": int64(5),
+					"string": "web:1",
+					"captures": []any{
+						map[string]any{"offset": int64(15), "length": int64(3), "string": "web", "name": "name"},
+						map[string]any{"offset": int64(19), "length": int64(1), "string": "1"},
+						map[string]any{"offset": int64(-1), "length": int64(0)},
+					},
+				},
+			},
+		},
+		{
+			`.dsn | capture("//(?P<user>\\w+)@(?P<host>\\w+):(?P<port>\\d+)(?P<opts>\\?.*)?")`,
+			[]any{map[string]any{"user": "admin", "host": "db", "port": "5432"}},
+		},
+		{`.hosts | capture("(?P<h>\\w+)"; "g") | .h`, []any{"alpha", "beta", "gamma"}},
+		{`.image | scan("\\d")`, []any{"1", "4", "2"}},
+		{`.image | scan("(\\d)\\.(x)?")`, []any{[]any{"1", ""}, []any{"4", ""}}},
+		{`.hosts | split(", *"; "")`, []any{[]any{"alpha", "beta", "gamma"}}},
+		{`.hosts | splits(", *")`, []any{"alpha", "beta", "gamma"}},
+		{`"a1b" | [splits("\\d*"; "n")]`, []any{[]any{"a", "b"}}},
+		{`"aaaaab" | match("b*"; "n") | .offset`, []any{int64(5)}},
+		{`"aaa", "aab" | test("b*"; "n")`, []any{false, true}},
+		{`"a-b" | sub("-*"; "+"; "n")`, []any{"a+b"}},
+		{`"a-b" | sub("-*"; "+")`, []any{"+a-b"}},
+		{`.image | sub("\\d+"; "X")`, []any{"registry.local/web:X.4.2"}},
+		{`.image | gsub("\\d+"; "X")`, []any{"registry.local/web:X.X.X"}},
+		{`.image | sub("\\d+"; "X"; "g")`, []any{"registry.local/web:X.X.X"}},
+		{`.image | sub("(?P<n>[a-z]+)$"; "")`, []any{"registry.local/web:1.4.2"}},
+		{`.dsn | sub("(?P<user>\\w+)@"; .user + "+ro@")`, []any{"postgres://admin+ro@db:5432/app"}},
+		{`"ab" | gsub("(?P<c>[ab])"; .c, "-")`, []any{"ab", "a-", "-b", "--"}},
+		{`"aaa" | sub("a+?"; "b"; "l")`, []any{"b"}},
+		{`"Ab" | gsub("a|B"; "-"; "i")`, []any{"--"}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if regular expression builtins report errors for invalid expressions,
+// flags, input data and replacements.
+func TestRegexpError(t *testing.T) {
+	data := map[string]any{"name": "web", "port": int64(80)}
+	cases := []struct {
+		query string
+		want  error
+	}{
+		{`.name | test("(")`, ErrRegexp},
+		{`.name | test("a"; "x")`, ErrArgumentValue},
+		{`.name | test(1)`, ErrTOMLDataType},
+		{`.name | test("a"; 1)`, ErrTOMLDataType},
+		{`.port | match("8")`, ErrTOMLDataType},
+		{`.port | capture("8")`, ErrTOMLDataType},
+		{`.name | scan("[")`, ErrRegexp},
+		{`.port | splits(",")`, ErrTOMLDataType},
+		{`.name | sub("w"; 1)`, ErrTOMLDataType},
+		{`.name | gsub("w"; "W"; "z")`, ErrArgumentValue},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			_, err := run(t, c.query, data)
+			if !errors.Is(err, c.want) {
+				t.Errorf("have: %v; want: %v", err, c.want)
+			}
+		})
+	}
+}
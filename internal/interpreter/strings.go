@@ -0,0 +1,496 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// texts runs the stream s against the data and passes every output as a
+// string to fn. The name of the calling builtin is used to report outputs that
+// are not strings.
+func texts(s stream, data any, name string, fn func(s string) error) error {
+	return s(data, func(v any) error {
+		str, ok := v.(string)
+		if !ok {
+			return typeError(v, name)
+		}
+		return fn(str)
+	})
+}
+
+// split splits the string on every output of the argument. The empty
+// separator splits the string into single characters, and the empty string
+// splits into the empty array.
+func split(data any, args []stream, emit emitter) error {
+	str, ok := data.(string)
+	if !ok {
+		return typeError(data, "split")
+	}
+	return texts(args[0], data, "split", func(sep string) error {
+		result := []any{}
+		if str != "" {
+			for _, s := range strings.Split(str, sep) {
+				result = append(result, s)
+			}
+		}
+		return emit(result)
+	})
+}
+
+// join joins the elements of the array with every output of the argument.
+// Numbers, booleans and date-times are converted to strings first.
+func join(data any, args []stream, emit emitter) error {
+	arr, ok := data.([]any)
+	if !ok {
+		return typeError(data, "join")
+	}
+	elems := make([]string, 0, len(arr))
+	for _, e := range arr {
+		switch e.(type) {
+		case []any, map[string]any:
+			return typeError(e, "join")
+		}
+		s, err := text(e)
+		if err != nil {
+			return err
+		}
+		elems = append(elems, s)
+	}
+	return texts(args[0], data, "join", func(sep string) error {
+		return emit(strings.Join(elems, sep))
+	})
+}
+
+// downcase converts ASCII letters of the string to lower case.
+func downcase(data any, _ []stream, emit emitter) error {
+	return mapASCII(data, "ascii_downcase", 'A', 'Z', 'a'-'A', emit)
+}
+
+// upcase converts ASCII letters of the string to upper case.
+func upcase(data any, _ []stream, emit emitter) error {
+	return mapASCII(data, "ascii_upcase", 'a', 'z', 'A'-'a', emit)
+}
+
+// mapASCII shifts characters of the string between lo and hi by delta and
+// leaves all other characters as they are.
+func mapASCII(data any, name string, lo, hi, delta rune, emit emitter) error {
+	str, ok := data.(string)
+	if !ok {
+		return typeError(data, name)
+	}
+	return emit(strings.Map(func(r rune) rune {
+		if r >= lo && r <= hi {
+			return r + delta
+		}
+		return r
+	}, str))
+}
+
+// ltrimstr removes every output of the argument from the start of the string
+// if the string starts with it. Any other input passes through unchanged.
+func ltrimstr(data any, args []stream, emit emitter) error {
+	return trimstr(data, args, strings.TrimPrefix, emit)
+}
+
+// rtrimstr removes every output of the argument from the end of the string if
+// the string ends with it. Any other input passes through unchanged.
+func rtrimstr(data any, args []stream, emit emitter) error {
+	return trimstr(data, args, strings.TrimSuffix, emit)
+}
+
+func trimstr(
+	data any,
+	args []stream,
+	fn func(s, affix string) string,
+	emit emitter,
+) error {
+	return args[0](data, func(v any) error {
+		str, sok := data.(string)
+		affix, aok := v.(string)
+		if !sok || !aok {
+			return emit(data)
+		}
+		return emit(fn(str, affix))
+	})
+}
+
+// trim removes leading and trailing whitespace from the string.
+func trim(data any, _ []stream, emit emitter) error {
+	return trimSpace(data, "trim", strings.TrimSpace, emit)
+}
+
+// ltrim removes leading whitespace from the string.
+func ltrim(data any, _ []stream, emit emitter) error {
+	return trimSpace(data, "ltrim", func(s string) string {
+		return strings.TrimLeftFunc(s, unicode.IsSpace)
+	}, emit)
+}
+
+// rtrim removes trailing whitespace from the string.
+func rtrim(data any, _ []stream, emit emitter) error {
+	return trimSpace(data, "rtrim", func(s string) string {
+		return strings.TrimRightFunc(s, unicode.IsSpace)
+	}, emit)
+}
+
+func trimSpace(
+	data any,
+	name string,
+	fn func(s string) string,
+	emit emitter,
+) error {
+	str, ok := data.(string)
+	if !ok {
+		return typeError(data, name)
+	}
+	return emit(fn(str))
+}
+
+// startsWith reports if the string starts with every output of the argument.
+func startsWith(data any, args []stream, emit emitter) error {
+	return affixed(data, args, "startswith", strings.HasPrefix, emit)
+}
+
+// endsWith reports if the string ends with every output of the argument.
+func endsWith(data any, args []stream, emit emitter) error {
+	return affixed(data, args, "endswith", strings.HasSuffix, emit)
+}
+
+func affixed(
+	data any,
+	args []stream,
+	name string,
+	fn func(s, affix string) bool,
+	emit emitter,
+) error {
+	str, ok := data.(string)
+	if !ok {
+		return typeError(data, name)
+	}
+	return texts(args[0], data, name, func(affix string) error {
+		return emit(fn(str, affix))
+	})
+}
+
+// containing reports if the data contains every output of the argument. See
+// included for what it means for one value to contain another one.
+func containing(data any, args []stream, emit emitter) error {
+	return args[0](data, func(v any) error {
+		if !sameKind(data, v) {
+			return &OperandError{data, v, "contains", ErrTOMLDataType}
+		}
+		return emit(included(data, v))
+	})
+}
+
+// included reports if the value a contains the value b. A string contains its
+// substrings, an array contains an array whose every element is contained by
+// one of its elements, and a table contains a table whose every key it has
+// with a value that contains the value of that key. Other values contain only
+// equal values.
+func included(a, b any) bool {
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		return ok && strings.Contains(x, y)
+	case []any:
+		y, ok := b.([]any)
+		if !ok {
+			return false
+		}
+		for _, be := range y {
+			if !slices.ContainsFunc(x, func(ae any) bool { return included(ae, be) }) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, bv := range y {
+			av, ok := x[k]
+			if !ok || !included(av, bv) {
+				return false
+			}
+		}
+		return true
+	}
+	return equal(a, b)
+}
+
+// sameKind reports if values a and b are of the same TOML data type. Integers
+// and floats are both numbers.
+func sameKind(a, b any) bool {
+	number := func(v any) bool {
+		switch v.(type) {
+		case int64, float64:
+			return true
+		}
+		return false
+	}
+	return typeName(a) == typeName(b) || number(a) && number(b)
+}
+
+// indices returns the positions of every output of the argument in the data.
+// Positions in strings count characters, and they may overlap. In arrays, an
+// array argument is found as a run of consecutive elements, and any other
+// argument is found as a single element.
+func indices(data any, args []stream, emit emitter) error {
+	return args[0](data, func(v any) error {
+		result, err := positions(data, v)
+		if err != nil {
+			return err
+		}
+		return emit(result)
+	})
+}
+
+// index returns the first position of every output of the argument in the
+// data. There is no output if the data does not contain it.
+func index(data any, args []stream, emit emitter) error {
+	return args[0](data, func(v any) error {
+		result, err := positions(data, v)
+		if err != nil || len(result) == 0 {
+			return err
+		}
+		return emit(result[0])
+	})
+}
+
+// rindex returns the last position of every output of the argument in the
+// data. There is no output if the data does not contain it.
+func rindex(data any, args []stream, emit emitter) error {
+	return args[0](data, func(v any) error {
+		result, err := positions(data, v)
+		if err != nil || len(result) == 0 {
+			return err
+		}
+		return emit(result[len(result)-1])
+	})
+}
+
+// positions finds all positions of the value v in the data for indices.
+func positions(data, v any) ([]any, error) {
+	result := []any{}
+	switch d := data.(type) {
+	case string:
+		s, ok := v.(string)
+		if !ok {
+			break
+		}
+		for i := 0; s != "" && i < len(d); {
+			n := strings.Index(d[i:], s)
+			if n < 0 {
+				break
+			}
+			i += n
+			result = append(result, int64(utf8.RuneCountInString(d[:i])))
+			_, size := utf8.DecodeRuneInString(d[i:])
+			i += size
+		}
+		return result, nil
+	case []any:
+		sub, ok := v.([]any)
+		if !ok {
+			sub = []any{v}
+		}
+		for i := 0; len(sub) > 0 && i+len(sub) <= len(d); i++ {
+			if slices.EqualFunc(d[i:i+len(sub)], sub, equal) {
+				result = append(result, int64(i))
+			}
+		}
+		return result, nil
+	}
+	return nil, &OperandError{data, v, "indices", ErrTOMLDataType}
+}
+
+// explode converts the string into the array of its Unicode code points.
+func explode(data any, _ []stream, emit emitter) error {
+	str, ok := data.(string)
+	if !ok {
+		return typeError(data, "explode")
+	}
+	result := make([]any, 0, len(str))
+	for _, r := range str {
+		result = append(result, int64(r))
+	}
+	return emit(result)
+}
+
+// implode converts the array of Unicode code points into a string.
+func implode(data any, _ []stream, emit emitter) error {
+	arr, ok := data.([]any)
+	if !ok {
+		return typeError(data, "implode")
+	}
+	var b strings.Builder
+	for _, e := range arr {
+		n, ok := e.(int64)
+		if !ok {
+			return typeError(e, "implode")
+		}
+		if n > utf8.MaxRune || !utf8.ValidRune(rune(n)) {
+			return &Error{data: e, filter: "implode", err: ErrConversion}
+		}
+		b.WriteRune(rune(n))
+	}
+	return emit(b.String())
+}
+
+// toString converts the data into a string with text.
+func toString(data any, _ []stream, emit emitter) error {
+	s, err := text(data)
+	if err != nil {
+		return err
+	}
+	return emit(s)
+}
+
+// toNumber parses the string into an integer or a float. Numbers pass through
+// unchanged.
+func toNumber(data any, _ []stream, emit emitter) error {
+	switch v := data.(type) {
+	case int64, float64:
+		return emit(v)
+	case string:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return emit(n)
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return emit(f)
+		}
+		return &Error{data: data, filter: "tonumber", err: ErrConversion}
+	}
+	return typeError(data, "tonumber")
+}
+
+// text returns the string itself, the TOML representation of a date-time or
+// of a float that is not finite, and the JSON representation of any other
+// value.
+func text(v any) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case float64:
+		switch {
+		case math.IsNaN(t):
+			return "nan", nil
+		case math.IsInf(t, 1):
+			return "inf", nil
+		case math.IsInf(t, -1):
+			return "-inf", nil
+		}
+	case time.Time:
+		return t.Format(time.RFC3339Nano), nil
+	case toml.LocalDateTime, toml.LocalDate, toml.LocalTime:
+		return t.(fmt.Stringer).String(), nil
+	}
+	var b strings.Builder
+	if err := encodeJSON(&b, v); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// encodeJSON writes the JSON representation of the value v to b. Date-times
+// are written as strings, and table keys are written sorted. JSON has no
+// infinities and no NaN, so they are written as the greatest finite numbers
+// and null respectively.
+func encodeJSON(b *strings.Builder, v any) error {
+	switch t := v.(type) {
+	case bool:
+		b.WriteString(strconv.FormatBool(t))
+	case int64:
+		b.WriteString(strconv.FormatInt(t, 10))
+	case float64:
+		switch {
+		case math.IsNaN(t):
+			b.WriteString("null")
+		case math.IsInf(t, 0):
+			b.WriteString(formatFloat(math.Copysign(math.MaxFloat64, t)))
+		default:
+			b.WriteString(formatFloat(t))
+		}
+	case string, time.Time, toml.LocalDateTime, toml.LocalDate, toml.LocalTime:
+		s, _ := text(t)
+		quote(b, s)
+	case []any:
+		b.WriteByte('[')
+		for n, e := range t {
+			if n > 0 {
+				b.WriteByte(',')
+			}
+			if err := encodeJSON(b, e); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case map[string]any:
+		b.WriteByte('{')
+		for n, k := range sortedKeys(t) {
+			if n > 0 {
+				b.WriteByte(',')
+			}
+			quote(b, k)
+			b.WriteByte(':')
+			if err := encodeJSON(b, t[k]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	default:
+		return typeError(v, "tojson")
+	}
+	return nil
+}
+
+// formatFloat formats the float the shortest way that keeps it apart from
+// integers, so that 1.0 does not turn into 1.
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// quote writes the string s to b as a quoted JSON string.
+func quote(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				b.WriteString(`\u00`)
+				b.WriteString(strconv.FormatInt(int64(r)>>4, 16))
+				b.WriteString(strconv.FormatInt(int64(r)&0xf, 16))
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+}
@@ -0,0 +1,152 @@
+package interpreter
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Test string manipulation builtins on strings found in TOML data.
+func TestStrings(t *testing.T) {
+	data := map[string]any{
+		"hosts":   "alpha.example.com,beta.example.com",
+		"name":    "  Web-01\t",
+		"city":    "Kraków",
+		"ports":   []any{int64(80), int64(443), int64(80)},
+		"empty":   []any{},
+		"tags":    []any{"web", int64(1), 1.5, true},
+		"since":   time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+		"date":    toml.LocalDate{Year: 1979, Month: 5, Day: 27},
+		"servers": map[string]any{"alpha": map[string]any{"ip": "10.0.0.1", "dc": "eqdc10"}},
+	}
+	cases := []struct {
+		query string
+		want  []any
+	}{
+		{`.hosts | split(",")`, []any{[]any{"alpha.example.com", "beta.example.com"}}},
+		{`.city | split("")`, []any{[]any{"K", "r", "a", "k", "ó", "w"}}},
+		{`"" | split(",")`, []any{[]any{}}},
+		{`.hosts | split(",") | join(";")`, []any{"alpha.example.com;beta.example.com"}},
+		{`.tags | join("-")`, []any{"web-1-1.5-true"}},
+		{`.empty | join(",")`, []any{""}},
+		{`.name | ascii_downcase`, []any{"  web-01\t"}},
+		{`.city | ascii_upcase`, []any{"KRAKóW"}},
+		{`.hosts | split(",")[] | rtrimstr(".example.com")`, []any{"alpha", "beta"}},
+		{`.hosts | ltrimstr("alpha.")`, []any{"example.com,beta.example.com"}},
+		{`.hosts | ltrimstr("beta.")`, []any{"alpha.example.com,beta.example.com"}},
+		{`.ports | ltrimstr("8")`, []any{[]any{int64(80), int64(443), int64(80)}}},
+		{`.name | trim`, []any{"Web-01"}},
+		{`.name | ltrim`, []any{"Web-01\t"}},
+		{`.name | rtrim`, []any{"  Web-01"}},
+		{`.hosts | startswith("alpha", "beta")`, []any{true, false}},
+		{`.hosts | endswith(".com")`, []any{true}},
+		{`.hosts | contains("beta")`, []any{true}},
+		{`.ports | contains([(443), (80)])`, []any{true}},
+		{`.ports | contains([(8080)])`, []any{false}},
+		{`.tags | contains([("we")])`, []any{true}},
+		{`.servers | contains({alpha: {ip: "10.0"}})`, []any{true}},
+		{`.servers | contains({beta: {}})`, []any{false}},
+		{`1 | contains(1.0)`, []any{true}},
+		{`"a,b, cd, efg, hi" | indices(", ")`, []any{[]any{int64(3), int64(7), int64(12)}}},
+		{`"aaa" | indices("aa")`, []any{[]any{int64(0), int64(1)}}},
+		{`.city | indices("w")`, []any{[]any{int64(5)}}},
+		{`.ports | indices(80)`, []any{[]any{int64(0), int64(2)}}},
+		{`.ports | indices([(80), (443)])`, []any{[]any{int64(0)}}},
+		{`.ports | indices(.[:0])`, []any{[]any{}}},
+		{`.hosts | index(".")`, []any{int64(5)}},
+		{`.hosts | rindex(".")`, []any{int64(30)}},
+		{`.hosts | index("gamma")`, []any{}},
+		{`.city | explode`, []any{[]any{int64(75), int64(114), int64(97), int64(107), int64(243), int64(119)}}},
+		{`.city | explode | implode`, []any{"Kraków"}},
+		{`.ports[0] | tostring`, []any{"80"}},
+		{`1.5, 1.0, 1e100 | tostring`, []any{"1.5", "1.0", "1e+100"}},
+		{`inf, -inf, nan | tostring`, []any{"inf", "-inf", "nan"}},
+		{`[inf, nan] | tostring`, []any{"[1.7976931348623157e+308,null]"}},
+		{`.city | tostring`, []any{"Kraków"}},
+		{`.since | tostring`, []any{"1979-05-27T07:32:00Z"}},
+		{`.date | tostring`, []any{"1979-05-27"}},
+		{`.tags | tostring`, []any{`["web",1,1.5,true]`}},
+		{`.servers | tostring`, []any{`{"alpha":{"dc":"eqdc10","ip":"10.0.0.1"}}`}},
+		{`"80", "-1.5", "1e3", 8 | tonumber`, []any{int64(80), -1.5, 1000.0, int64(8)}},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			have, err := run(t, c.query, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if string manipulation builtins report errors for data and
+// arguments they cannot handle.
+func TestStringsError(t *testing.T) {
+	data := map[string]any{
+		"name":   "web",
+		"port":   int64(80),
+		"nested": []any{[]any{"a"}},
+		"points": []any{int64(-1)},
+	}
+	cases := []struct {
+		query string
+		want  error
+	}{
+		{`.port | split(",")`, ErrTOMLDataType},
+		{`.name | split(1)`, ErrTOMLDataType},
+		{`.name | join(",")`, ErrTOMLDataType},
+		{`.nested | join(",")`, ErrTOMLDataType},
+		{`.port | ascii_downcase`, ErrTOMLDataType},
+		{`.port | trim`, ErrTOMLDataType},
+		{`.port | startswith("8")`, ErrTOMLDataType},
+		{`.name | endswith(1)`, ErrTOMLDataType},
+		{`.name | contains(1)`, ErrTOMLDataType},
+		{`.port | indices(8)`, ErrTOMLDataType},
+		{`.name | indices(1)`, ErrTOMLDataType},
+		{`.port | explode`, ErrTOMLDataType},
+		{`.nested | implode`, ErrTOMLDataType},
+		{`.points | implode`, ErrConversion},
+		{`.name | tonumber`, ErrConversion},
+		{`.nested | tonumber`, ErrTOMLDataType},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			_, err := run(t, c.query, data)
+			if !errors.Is(err, c.want) {
+				t.Errorf("have: %v; want: %v", err, c.want)
+			}
+		})
+	}
+}
+
+// Check if values are encoded as JSON with escaped strings and sorted keys.
+func TestEncodeJSON(t *testing.T) {
+	cases := []struct {
+		data any
+		want string
+	}{
+		{"a\"b\\c\n\x01", `"a\"b\\c\n\u0001"`},
+		{math.Inf(-1), "-1.7976931348623157e+308"},
+		{[]any{}, "[]"},
+		{map[string]any{"b": false, "a": toml.LocalTime{Hour: 7}}, `{"a":"07:00:00","b":false}`},
+	}
+	for _, c := range cases {
+		t.Run(c.want, func(t *testing.T) {
+			var b strings.Builder
+			if err := encodeJSON(&b, c.data); err != nil {
+				t.Fatal(err)
+			}
+			if have := b.String(); have != c.want {
+				t.Errorf("have: %s; want: %s", have, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package interpreter
+
+import (
+	"cmp"
+	"math"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// truthy reports if the value v counts as true in a boolean context. TOML has
+// no null value, so false is the only value that counts as false.
+func truthy(v any) bool {
+	b, ok := v.(bool)
+	return !ok || b
+}
+
+// typeName returns the name of the TOML data type of the value v.
+func typeName(v any) string {
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case int64:
+		return "integer"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case time.Time:
+		return "offset-datetime"
+	case toml.LocalDateTime:
+		return "local-datetime"
+	case toml.LocalDate:
+		return "local-date"
+	case toml.LocalTime:
+		return "local-time"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "table"
+	default:
+		return "unknown"
+	}
+}
+
+// equal reports if values a and b are equal. Values of kinds that cannot be
+// compared with one another are never equal, and NaN is not equal to anything,
+// not even to itself, wherever it appears in arrays and tables.
+func equal(a, b any) bool {
+	c, ok := compareEqual(a, b)
+	return ok && c == 0
+}
+
+// compareEqual compares values a and b the way compare does, but NaN cannot be
+// compared with any value. Unlike compare, it does not order values, and it is
+// only meant for telling if they are equal.
+func compareEqual(a, b any) (int, bool) {
+	if isNaN(a) || isNaN(b) {
+		return 0, false
+	}
+	switch l := a.(type) {
+	case []any:
+		if r, ok := b.([]any); ok {
+			return compareArrays(l, r, compareEqual)
+		}
+	case map[string]any:
+		if r, ok := b.(map[string]any); ok {
+			return compareTables(l, r, compareEqual)
+		}
+	}
+	return compare(a, b)
+}
+
+func isNaN(v any) bool {
+	f, ok := v.(float64)
+	return ok && math.IsNaN(f)
+}
+
+// compare compares values a and b. It returns -1 if a is less than b, 0 if
+// they are equal, and +1 if a is greater than b. The boolean return value
+// reports if the two values can be compared at all.
+//
+// Integers and floats are compared with one another by their numeric value.
+// NaN is less than any other number and sorts together with itself, which
+// keeps the order total for sorting; equal still never finds it equal. Offset
+// date-times are compared as instants in time; local date-times, local dates
+// and local times are compared only with values of the same kind. Arrays are
+// compared element by element. Tables are compared by their sorted keys first
+// and then by the values stored under these keys.
+func compare(a, b any) (int, bool) {
+	switch l := a.(type) {
+	case bool:
+		if r, ok := b.(bool); ok {
+			return compareBools(l, r), true
+		}
+	case int64:
+		switch r := b.(type) {
+		case int64:
+			return cmp.Compare(l, r), true
+		case float64:
+			return compareIntFloat(l, r), true
+		}
+	case float64:
+		switch r := b.(type) {
+		case int64:
+			return -compareIntFloat(r, l), true
+		case float64:
+			return cmp.Compare(l, r), true
+		}
+	case string:
+		if r, ok := b.(string); ok {
+			return cmp.Compare(l, r), true
+		}
+	case time.Time:
+		if r, ok := b.(time.Time); ok {
+			return l.Compare(r), true
+		}
+	case toml.LocalDateTime:
+		if r, ok := b.(toml.LocalDateTime); ok {
+			return l.AsTime(time.UTC).Compare(r.AsTime(time.UTC)), true
+		}
+	case toml.LocalDate:
+		if r, ok := b.(toml.LocalDate); ok {
+			return l.AsTime(time.UTC).Compare(r.AsTime(time.UTC)), true
+		}
+	case toml.LocalTime:
+		if r, ok := b.(toml.LocalTime); ok {
+			return cmp.Compare(nanoseconds(l), nanoseconds(r)), true
+		}
+	case []any:
+		if r, ok := b.([]any); ok {
+			return compareArrays(l, r, compare)
+		}
+	case map[string]any:
+		if r, ok := b.(map[string]any); ok {
+			return compareTables(l, r, compare)
+		}
+	}
+	return 0, false
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case !a:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// compareIntFloat compares the integer a with the float b without losing the
+// precision of integers that cannot be represented exactly as floats.
+func compareIntFloat(a int64, b float64) int {
+	switch {
+	case math.IsNaN(b):
+		return 1
+	case b >= math.MaxInt64:
+		return -1
+	case b < math.MinInt64:
+		return 1
+	}
+	floor := math.Floor(b)
+	if c := cmp.Compare(a, int64(floor)); c != 0 {
+		return c
+	}
+	if floor < b {
+		return -1
+	}
+	return 0
+}
+
+// nanoseconds returns the number of nanoseconds since midnight for the local
+// time t.
+func nanoseconds(t toml.LocalTime) int64 {
+	d := time.Duration(t.Hour)*time.Hour +
+		time.Duration(t.Minute)*time.Minute +
+		time.Duration(t.Second)*time.Second +
+		time.Duration(t.Nanosecond)
+	return int64(d)
+}
+
+// compareArrays compares arrays a and b element by element with the function
+// fn comparing single elements.
+func compareArrays(a, b []any, fn func(a, b any) (int, bool)) (int, bool) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		c, ok := fn(a[i], b[i])
+		if !ok || c != 0 {
+			return c, ok
+		}
+	}
+	return cmp.Compare(len(a), len(b)), true
+}
+
+// compareTables compares tables a and b by their sorted keys first and then by
+// their values with the function fn comparing single values.
+func compareTables(a, b map[string]any, fn func(a, b any) (int, bool)) (int, bool) {
+	ka, kb := sortedKeys(a), sortedKeys(b)
+	for i := 0; i < len(ka) && i < len(kb); i++ {
+		if c := cmp.Compare(ka[i], kb[i]); c != 0 {
+			return c, true
+		}
+	}
+	if c := cmp.Compare(len(ka), len(kb)); c != 0 {
+		return c, true
+	}
+	for _, k := range ka {
+		c, ok := fn(a[k], b[k])
+		if !ok || c != 0 {
+			return c, ok
+		}
+	}
+	return 0, true
+}
+
+// order compares values a and b of any kind. It extends compare to a total
+// ordering that sorts values by their kind first: booleans, numbers, strings,
+// offset date-times, local date-times, local dates, local times, arrays and
+// tables in this order.
+func order(a, b any) int {
+	if c := cmp.Compare(rank(a), rank(b)); c != 0 {
+		return c
+	}
+	total := func(a, b any) (int, bool) { return order(a, b), true }
+	var c int
+	switch l := a.(type) {
+	case []any:
+		c, _ = compareArrays(l, b.([]any), total)
+	case map[string]any:
+		c, _ = compareTables(l, b.(map[string]any), total)
+	default:
+		c, _ = compare(a, b)
+	}
+	return c
+}
+
+// rank returns the position of the kind of the value v in the total ordering.
+func rank(v any) int {
+	switch v.(type) {
+	case bool:
+		return 0
+	case int64, float64:
+		return 1
+	case string:
+		return 2
+	case time.Time:
+		return 3
+	case toml.LocalDateTime:
+		return 4
+	case toml.LocalDate:
+		return 5
+	case toml.LocalTime:
+		return 6
+	case []any:
+		return 7
+	case map[string]any:
+		return 8
+	default:
+		return 9
+	}
+}
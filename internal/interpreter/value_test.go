@@ -0,0 +1,167 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Check if only the boolean false counts as false in a boolean context.
+func TestTruthy(t *testing.T) {
+	cases := []struct {
+		value any
+		want  bool
+	}{
+		{true, true},
+		{false, false},
+		{int64(0), true},
+		{"", true},
+		{[]any{}, true},
+		{map[string]any{}, true},
+	}
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%v", c.value), func(t *testing.T) {
+			if have := truthy(c.value); have != c.want {
+				t.Errorf("have: %t; want: %t", have, c.want)
+			}
+		})
+	}
+}
+
+// Test the comparison of values of TOML data types.
+func TestCompare(t *testing.T) {
+	offset := time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC)
+	date := toml.LocalDate{Year: 1979, Month: 5, Day: 27}
+	clock := toml.LocalTime{Hour: 7, Minute: 32}
+	cases := []struct {
+		name string
+		a, b any
+		want int
+		ok   bool
+	}{
+		{"false-true", false, true, -1, true},
+		{"true-true", true, true, 0, true},
+		{"int-int", int64(1), int64(2), -1, true},
+		{"int-float-equal", int64(2), 2.0, 0, true},
+		{"int-float-fraction", int64(2), 2.5, -1, true},
+		{"float-int", 2.5, int64(2), 1, true},
+		{"int-float-negative", int64(-3), -2.5, -1, true},
+		{"int-float-large", int64(math.MaxInt64), 9.3e18, -1, true},
+		{"int-float-small", int64(math.MinInt64), -9.3e18, 1, true},
+		{"int-inf", int64(math.MaxInt64), math.Inf(1), -1, true},
+		{"int-nan", int64(0), math.NaN(), 1, true},
+		{"nan-nan", math.NaN(), math.NaN(), 0, true},
+		{"string-string", "alpha", "beta", -1, true},
+		{"offset-offset", offset, offset.Add(-time.Hour), 1, true},
+		{
+			"offset-zones",
+			offset,
+			time.Date(1979, 5, 27, 0, 32, 0, 0, time.FixedZone("", -7*3600)),
+			0,
+			true,
+		},
+		{
+			"local-datetime",
+			toml.LocalDateTime{LocalDate: date, LocalTime: clock},
+			toml.LocalDateTime{LocalDate: date, LocalTime: toml.LocalTime{Hour: 8}},
+			-1,
+			true,
+		},
+		{"local-date", date, toml.LocalDate{Year: 1979, Month: 5, Day: 26}, 1, true},
+		{"local-time", clock, toml.LocalTime{Hour: 7, Minute: 32, Nanosecond: 1}, -1, true},
+		{"array-prefix", []any{int64(1)}, []any{int64(1), int64(2)}, -1, true},
+		{"array-element", []any{int64(3)}, []any{int64(1), int64(2)}, 1, true},
+		{"array-equal", []any{"a", 1.0}, []any{"a", int64(1)}, 0, true},
+		{"array-mixed", []any{"a"}, []any{int64(1)}, 0, false},
+		{"table-keys", map[string]any{"a": int64(9)}, map[string]any{"b": int64(1)}, -1, true},
+		{"table-values", map[string]any{"a": int64(9)}, map[string]any{"a": int64(1)}, 1, true},
+		{"table-size", map[string]any{"a": int64(1)}, map[string]any{"a": int64(1), "b": true}, -1, true},
+		{"table-equal", map[string]any{"a": []any{}}, map[string]any{"a": []any{}}, 0, true},
+		{"string-int", "1", int64(1), 0, false},
+		{"offset-local", offset, date, 0, false},
+		{"bool-int", true, int64(1), 0, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			have, ok := compare(c.a, c.b)
+			if ok != c.ok {
+				t.Fatalf("have: %t; want: %t", ok, c.ok)
+			}
+			if have != c.want {
+				t.Errorf("have: %d; want: %d", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if values of incomparable kinds are never equal.
+func TestEqual(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b any
+		want bool
+	}{
+		{"int-float", int64(1), 1.0, true},
+		{"string-string", "a", "a", true},
+		{"string-int", "1", int64(1), false},
+		{"array-mixed", []any{"a"}, []any{int64(1)}, false},
+		{"table-table", map[string]any{"a": "b"}, map[string]any{"a": "b"}, true},
+		{"nan-nan", math.NaN(), math.NaN(), false},
+		{"nan-int", math.NaN(), int64(0), false},
+		{"array-nan", []any{math.NaN()}, []any{math.NaN()}, false},
+		{"table-nan", map[string]any{"a": math.NaN()}, map[string]any{"a": math.NaN()}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if have := equal(c.a, c.b); have != c.want {
+				t.Errorf("have: %t; want: %t", have, c.want)
+			}
+		})
+	}
+}
+
+// Test the total ordering of values of all TOML data types.
+func TestOrder(t *testing.T) {
+	offset := time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC)
+	date := toml.LocalDate{Year: 1979, Month: 5, Day: 27}
+	clock := toml.LocalTime{Hour: 7, Minute: 32}
+	ascending := []any{
+		false,
+		true,
+		math.NaN(),
+		int64(-1),
+		0.5,
+		int64(1),
+		"",
+		"a",
+		offset,
+		toml.LocalDateTime{LocalDate: date, LocalTime: clock},
+		date,
+		clock,
+		[]any{},
+		[]any{int64(1)},
+		[]any{"a"},
+		map[string]any{},
+		map[string]any{"a": int64(1)},
+		map[string]any{"a": "b"},
+	}
+	for i := range ascending {
+		for j := range ascending {
+			a, b := ascending[i], ascending[j]
+			t.Run(fmt.Sprintf("%v-%v", a, b), func(t *testing.T) {
+				want := 0
+				if i < j {
+					want = -1
+				} else if i > j {
+					want = 1
+				}
+				if have := order(a, b); have != want {
+					t.Errorf("have: %d; want: %d", have, want)
+				}
+			})
+		}
+	}
+}
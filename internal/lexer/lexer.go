@@ -23,6 +23,19 @@ type Lexer struct {
 	offset     int
 	lineOffset int
 	curr       Token
+	prev       TokenType // type of the last token other than white space
+
+	// interpolations holds strings with interpolated expressions that are
+	// being scanned, the innermost one last.
+	interpolations []interpolation
+}
+
+// interpolation keeps track of the quoted string with an interpolated
+// expression. The string resumes at the closing parenthesis that matches the
+// opening one of the expression.
+type interpolation struct {
+	quote rune // quote character that terminates the string
+	depth int  // number of parentheses open inside the expression
 }
 
 // New returns a new Lexer with its buffer populated with scanner tokens read
@@ -62,14 +75,29 @@ func (l *Lexer) Scan() bool {
 	if l.offset > len(l.buffer)-1 {
 		return false
 	}
+	if l.curr.Type != Whitespace {
+		l.prev = l.curr.Type
+	}
 	t := l.buffer[l.offset]
 	switch r := t.Rune; {
+	case r == ')' && l.closesInterpolation():
+		return l.scanStringTail()
+	case l.lookahead("?//"):
+		return l.scanAlternative()
+	case isOperator(r, l.peekRune()):
+		return l.scanOperator()
 	case isKeyChar(r):
 		return l.scanKeyChar()
 	case isQuote(r):
 		return l.scanString()
-	case isDigit(r):
-		return l.scanInteger()
+	case isMinus(r) && (l.prev.endsOperand() || !isBareChar(l.peekRune())):
+		return l.scanMinus()
+	case isDigit(r), isMinus(r) && isDigit(l.peekRune()):
+		return l.scanNumber()
+	case isFormat(r) && isBareChar(l.peekRune()):
+		return l.scanFormat()
+	case isVariable(r) && isNameChar(l.peekRune()) && !isDigit(l.peekRune()):
+		return l.scanVariable()
 	case isBareChar(r):
 		return l.scanBareString()
 	case isWhitespace(r):
@@ -148,11 +176,57 @@ func (l *Lexer) scanKeyChar() bool {
 		l.pushErr(ErrKeyCharUnsupported)
 		return false
 	}
+	if n := len(l.interpolations) - 1; n >= 0 {
+		switch tp {
+		case ParenOpen:
+			l.interpolations[n].depth++
+		case ParenClose:
+			l.interpolations[n].depth--
+		}
+	}
 	l.setToken(tp, l.offset, l.offset+1)
 	l.advance()
 	return true
 }
 
+func (l *Lexer) scanOperator() bool {
+	op := string([]rune{l.buffer[l.offset].Rune, l.peekRune()})
+	tp, ok := operatorMap[op]
+	if !ok {
+		l.pushErr(ErrKeyCharUnsupported)
+		return false
+	}
+	l.setToken(tp, l.offset, l.offset+2)
+	l.advance()
+	l.advance()
+	return true
+}
+
+// scanAlternative scans the ?// token separating alternative destructuring
+// patterns.
+func (l *Lexer) scanAlternative() bool {
+	l.setToken(Alternative, l.offset, l.offset+3)
+	l.skip(3)
+	return true
+}
+
+// scanMinus scans the minus sign as the subtraction operator. A minus sign
+// opening an operand is a part of the integer or the bare string instead.
+func (l *Lexer) scanMinus() bool {
+	l.setToken(Minus, l.offset, l.offset+1)
+	l.advance()
+	return true
+}
+
+// peekRune returns the rune following the current Lexer offset or zero value
+// if the current offset points at the last rune in the buffer.
+func (l *Lexer) peekRune() rune {
+	if l.offset+1 > len(l.buffer)-1 {
+		return 0
+	}
+	return l.buffer[l.offset+1].Rune
+}
+
 func (l *Lexer) scanBareString() bool {
 	t := l.buffer[l.offset]
 	start := l.offset
@@ -165,16 +239,60 @@ func (l *Lexer) scanBareString() bool {
 		l.advance()
 	}
 	l.setToken(String, start, l.offset)
+	if tp, ok := keywordMap[l.curr.Lexeme()]; ok {
+		l.curr.Type = tp
+	}
+	return true
+}
+
+// scanFormat scans the format string made of the @ character followed by
+// the name of the format.
+func (l *Lexer) scanFormat() bool {
+	start := l.offset
+	l.advance()
+	for l.offset <= len(l.buffer)-1 && isBareChar(l.buffer[l.offset].Rune) {
+		l.advance()
+	}
+	l.setToken(Format, start, l.offset)
+	return true
+}
+
+// scanVariable scans the variable reference made of the $ character followed
+// by the name of the variable.
+func (l *Lexer) scanVariable() bool {
+	start := l.offset
+	l.advance()
+	for l.offset <= len(l.buffer)-1 && isNameChar(l.buffer[l.offset].Rune) {
+		l.advance()
+	}
+	l.setToken(Variable, start, l.offset)
 	return true
 }
 
 func (l *Lexer) scanString() bool {
-	var prev scanner.Token
 	t := l.buffer[l.offset]
-	tq := t.Rune
 	start := l.offset
-	prev = t
 	l.advance()
+	return l.scanStringBody(t.Rune, start, String, InterpolationStart)
+}
+
+// scanStringTail scans the rest of the quoted string that resumes after the
+// interpolated expression.
+func (l *Lexer) scanStringTail() bool {
+	n := len(l.interpolations) - 1
+	quote := l.interpolations[n].quote
+	l.interpolations = l.interpolations[:n]
+	start := l.offset
+	l.advance()
+	return l.scanStringBody(quote, start, InterpolationEnd, InterpolationMiddle)
+}
+
+// scanStringBody scans characters of the quoted string up to the quote
+// character. The string token is of the type closed if the quote character
+// terminates it, and it is of the type open if the \( escape sequence starts
+// an interpolated expression first.
+func (l *Lexer) scanStringBody(quote rune, start int, closed, open TokenType) bool {
+	escaped := false
 	for {
 		if l.offset > len(l.buffer)-1 {
 			// NOTE: This error is reported because the string goes past the
@@ -184,32 +302,117 @@ func (l *Lexer) scanString() bool {
 			l.pushErr(ErrUnterminatedString)
 			return false
 		}
-		l.resetLineOffsetOnLineBreak(t.Rune)
-		t = l.buffer[l.offset]
-		if t.Rune == tq && prev.Rune != '\\' {
-			prev = t
+		t := l.buffer[l.offset]
+		switch {
+		case escaped && t.Rune == '(':
 			l.advance()
-			break
+			l.interpolations = append(l.interpolations, interpolation{quote: quote})
+			l.setToken(open, start, l.offset)
+			return true
+		case escaped:
+			escaped = false
+		case t.Rune == '\\':
+			escaped = true
+		case t.Rune == quote:
+			l.advance()
+			l.setToken(closed, start, l.offset)
+			return true
 		}
-		prev = t
 		l.advance()
+		l.resetLineOffsetOnLineBreak(t.Rune)
 	}
-	l.setToken(String, start, l.offset)
-	return true
 }
 
-func (l *Lexer) scanInteger() bool {
-	t := l.buffer[l.offset]
+// closesInterpolation reports if the closing parenthesis at the current offset
+// terminates the innermost interpolated expression.
+func (l *Lexer) closesInterpolation() bool {
+	n := len(l.interpolations) - 1
+	return n >= 0 && l.interpolations[n].depth == 0
+}
+
+// scanNumber scans an integer, a float or a date-time. Floats have a fraction,
+// an exponent or both. Date-times start with a full date such as 1979-05-27
+// optionally followed by the time and the offset.
+func (l *Lexer) scanNumber() bool {
 	start := l.offset
-	l.advance()
-	for l.offset <= len(l.buffer)-1 {
-		t = l.buffer[l.offset]
-		if !isDigit(t.Rune) {
+	if isMinus(l.buffer[l.offset].Rune) {
+		l.advance()
+	}
+	if l.skipDigits() == 4 && start == l.offset-4 && l.scanDateTime() {
+		l.setToken(DateTime, start, l.offset)
+		return true
+	}
+	tp := Integer
+	if l.lookahead(".9") {
+		l.advance()
+		l.skipDigits()
+		tp = Float
+	}
+	for _, exp := range []string{"e9", "E9", "e+9", "E+9", "e-9", "E-9"} {
+		if l.lookahead(exp) {
+			l.skip(len(exp) - 1)
+			l.skipDigits()
+			tp = Float
 			break
 		}
+	}
+	l.setToken(tp, start, l.offset)
+	return true
+}
+
+// scanDateTime scans the remainder of a date-time following the year.
+func (l *Lexer) scanDateTime() bool {
+	if !l.lookahead("-99-99") {
+		return false
+	}
+	l.skip(6)
+	if !l.lookahead("T99:99:99") && !l.lookahead("t99:99:99") {
+		return true
+	}
+	l.skip(9)
+	if l.lookahead(".9") {
+		l.advance()
+		l.skipDigits()
+	}
+	switch {
+	case l.lookahead("Z"), l.lookahead("z"):
+		l.advance()
+	case l.lookahead("+99:99"), l.lookahead("-99:99"):
+		l.skip(6)
+	}
+	return true
+}
+
+// skipDigits advances past consecutive digits and returns their number.
+func (l *Lexer) skipDigits() int {
+	n := 0
+	for l.offset <= len(l.buffer)-1 && isDigit(l.buffer[l.offset].Rune) {
 		l.advance()
+		n++
+	}
+	return n
+}
+
+func (l *Lexer) skip(n int) {
+	for range n {
+		l.advance()
+	}
+}
+
+// lookahead reports if the runes starting at the current Lexer offset follow
+// the pattern, where 9 stands for any digit and other runes for themselves.
+func (l *Lexer) lookahead(pattern string) bool {
+	i := l.offset
+	for _, p := range pattern {
+		if i > len(l.buffer)-1 {
+			return false
+		}
+		r := l.buffer[i].Rune
+		if p == '9' && !isDigit(r) || p != '9' && r != p {
+			return false
+		}
+		i++
 	}
-	l.setToken(Integer, start, l.offset)
 	return true
 }
 
@@ -92,6 +92,31 @@ func TestLexerScanError(t *testing.T) {
 			query: "['texts'][  ]['chars'].[$1] 22\r",
 			want:  ErrDisallowedChar,
 		},
+		{
+			name:  "lone-equal-sign",
+			query: ".a = 1",
+			want:  ErrDisallowedChar,
+		},
+		{
+			name:  "lone-exclamation-mark",
+			query: "!.a",
+			want:  ErrDisallowedChar,
+		},
+		{
+			name:  "unterminated-interpolated-string",
+			query: `"\(.a)`,
+			want:  ErrUnterminatedString,
+		},
+		{
+			name:  "lone-at-sign",
+			query: "@ .a",
+			want:  ErrDisallowedChar,
+		},
+		{
+			name:  "variable-starting-with-digit",
+			query: ".a as $1",
+			want:  ErrDisallowedChar,
+		},
 		{
 			name:  "dissallowed-char-in-string",
 			query: "['parent'].$",
@@ -170,6 +195,243 @@ func TestLexerScanAll(t *testing.T) {
 				{Whitespace, nil, 22, 23, 23},
 			},
 		},
+		{
+			name:             "signed integers",
+			query:            "[-1][-3:] -a a-1",
+			ignoreWhitespace: true,
+			want: []Token{
+				{ArrayOpen, nil, 0, 1, 0},
+				{Integer, nil, 1, 3, 3},
+				{ArrayClose, nil, 3, 4, 3},
+				{ArrayOpen, nil, 4, 5, 4},
+				{Integer, nil, 5, 7, 7},
+				{Colon, nil, 7, 8, 7},
+				{ArrayClose, nil, 8, 9, 8},
+				{Minus, nil, 10, 11, 10},
+				{String, nil, 11, 12, 12},
+				{String, nil, 13, 16, 16},
+			},
+		},
+		{
+			name:             "arithmetic operators",
+			query:            ".a -1+2*3/.b%4 - -5 -.c",
+			ignoreWhitespace: true,
+			want: []Token{
+				{Dot, nil, 0, 1, 0},
+				{String, nil, 1, 2, 2},
+				{Minus, nil, 3, 4, 3},
+				{Integer, nil, 4, 5, 5},
+				{Plus, nil, 5, 6, 5},
+				{Integer, nil, 6, 7, 7},
+				{Star, nil, 7, 8, 7},
+				{Integer, nil, 8, 9, 9},
+				{Slash, nil, 9, 10, 9},
+				{Dot, nil, 10, 11, 10},
+				{String, nil, 11, 12, 12},
+				{Percent, nil, 12, 13, 12},
+				{Integer, nil, 13, 14, 14},
+				{Minus, nil, 15, 16, 15},
+				{Integer, nil, 17, 19, 19},
+				{Minus, nil, 20, 21, 20},
+				{Dot, nil, 21, 22, 21},
+				{String, nil, 22, 23, 23},
+			},
+		},
+		{
+			name:             "operators and keywords",
+			query:            ".a==1 and .b!=2 or not|<<=>>=",
+			ignoreWhitespace: true,
+			want: []Token{
+				{Dot, nil, 0, 1, 0},
+				{String, nil, 1, 2, 2},
+				{Equal, nil, 2, 4, 2},
+				{Integer, nil, 4, 5, 5},
+				{And, nil, 6, 9, 9},
+				{Dot, nil, 10, 11, 10},
+				{String, nil, 11, 12, 12},
+				{NotEqual, nil, 12, 14, 12},
+				{Integer, nil, 14, 15, 15},
+				{Or, nil, 16, 18, 18},
+				{String, nil, 19, 22, 22},
+				{Pipe, nil, 22, 23, 22},
+				{Less, nil, 23, 24, 23},
+				{LessEqual, nil, 24, 26, 24},
+				{Greater, nil, 26, 27, 26},
+				{GreaterEqual, nil, 27, 29, 27},
+			},
+		},
+		{
+			name:             "recursive descent",
+			query:            "..|.ip",
+			ignoreWhitespace: true,
+			want: []Token{
+				{DoubleDot, nil, 0, 2, 0},
+				{Pipe, nil, 2, 3, 2},
+				{Dot, nil, 3, 4, 3},
+				{String, nil, 4, 6, 6},
+			},
+		},
+		{
+			name:             "literals",
+			query:            "3.14, -2e-3, 1E6, 1., true, false, inf, -inf, nan, 1979-05-27, 1979-05-27T07:32:00.5z, 1979-05-27t07:32:00-08:00, 1979-05",
+			ignoreWhitespace: true,
+			want: []Token{
+				{Float, nil, 0, 4, 4},
+				{Comma, nil, 4, 5, 4},
+				{Float, nil, 6, 11, 11},
+				{Comma, nil, 11, 12, 11},
+				{Float, nil, 13, 16, 16},
+				{Comma, nil, 16, 17, 16},
+				{Integer, nil, 18, 19, 19},
+				{Dot, nil, 19, 20, 19},
+				{Comma, nil, 20, 21, 20},
+				{Boolean, nil, 22, 26, 26},
+				{Comma, nil, 26, 27, 26},
+				{Boolean, nil, 28, 33, 33},
+				{Comma, nil, 33, 34, 33},
+				{Float, nil, 35, 38, 38},
+				{Comma, nil, 38, 39, 38},
+				{Float, nil, 40, 44, 44},
+				{Comma, nil, 44, 45, 44},
+				{Float, nil, 46, 49, 49},
+				{Comma, nil, 49, 50, 49},
+				{DateTime, nil, 51, 61, 61},
+				{Comma, nil, 61, 62, 61},
+				{DateTime, nil, 63, 85, 85},
+				{Comma, nil, 85, 86, 85},
+				{DateTime, nil, 87, 112, 112},
+				{Comma, nil, 112, 113, 112},
+				{Integer, nil, 114, 118, 118},
+				{Minus, nil, 118, 119, 118},
+				{Integer, nil, 119, 121, 121},
+			},
+		},
+		{
+			name:             "function calls",
+			query:            "has(.a; 1) | .select",
+			ignoreWhitespace: true,
+			want: []Token{
+				{String, nil, 0, 3, 3},
+				{ParenOpen, nil, 3, 4, 3},
+				{Dot, nil, 4, 5, 4},
+				{String, nil, 5, 6, 6},
+				{Semicolon, nil, 6, 7, 6},
+				{Integer, nil, 8, 9, 9},
+				{ParenClose, nil, 9, 10, 9},
+				{Pipe, nil, 11, 12, 11},
+				{Dot, nil, 13, 14, 13},
+				{String, nil, 14, 20, 20},
+			},
+		},
+		{
+			name:             "string interpolation",
+			query:            `"a\(.b | (1))-\("\(2)")" + "\\"`,
+			ignoreWhitespace: true,
+			want: []Token{
+				{InterpolationStart, nil, 0, 4, 4},
+				{Dot, nil, 4, 5, 4},
+				{String, nil, 5, 6, 6},
+				{Pipe, nil, 7, 8, 7},
+				{ParenOpen, nil, 9, 10, 9},
+				{Integer, nil, 10, 11, 11},
+				{ParenClose, nil, 11, 12, 11},
+				{InterpolationMiddle, nil, 12, 16, 16},
+				{InterpolationStart, nil, 16, 19, 19},
+				{Integer, nil, 19, 20, 20},
+				{InterpolationEnd, nil, 20, 22, 22},
+				{InterpolationEnd, nil, 22, 24, 24},
+				{Plus, nil, 25, 26, 25},
+				{String, nil, 27, 31, 31},
+			},
+		},
+		{
+			name:             "format strings",
+			query:            `@csv, @base64d "\(.)"`,
+			ignoreWhitespace: true,
+			want: []Token{
+				{Format, nil, 0, 4, 4},
+				{Comma, nil, 4, 5, 4},
+				{Format, nil, 6, 14, 14},
+				{InterpolationStart, nil, 15, 18, 18},
+				{Dot, nil, 18, 19, 18},
+				{InterpolationEnd, nil, 19, 21, 21},
+			},
+		},
+		{
+			name:             "variables",
+			query:            ".a as $x | $x -1, $__loc__",
+			ignoreWhitespace: true,
+			want: []Token{
+				{Dot, nil, 0, 1, 0},
+				{String, nil, 1, 2, 2},
+				{As, nil, 3, 5, 5},
+				{Variable, nil, 6, 8, 8},
+				{Pipe, nil, 9, 10, 9},
+				{Variable, nil, 11, 13, 13},
+				{Minus, nil, 14, 15, 14},
+				{Integer, nil, 15, 16, 16},
+				{Comma, nil, 16, 17, 16},
+				{Variable, nil, 18, 26, 26},
+			},
+		},
+		{
+			name:             "function definitions",
+			query:            "def f($a; g): .def; f",
+			ignoreWhitespace: true,
+			want: []Token{
+				{Def, nil, 0, 3, 3},
+				{String, nil, 4, 5, 5},
+				{ParenOpen, nil, 5, 6, 5},
+				{Variable, nil, 6, 8, 8},
+				{Semicolon, nil, 8, 9, 8},
+				{String, nil, 10, 11, 11},
+				{ParenClose, nil, 11, 12, 11},
+				{Colon, nil, 12, 13, 12},
+				{Dot, nil, 14, 15, 14},
+				{Def, nil, 15, 18, 18},
+				{Semicolon, nil, 18, 19, 18},
+				{String, nil, 20, 21, 21},
+			},
+		},
+		{
+			name:             "reductions",
+			query:            "reduce .[] as $x (0; .), foreach",
+			ignoreWhitespace: true,
+			want: []Token{
+				{Reduce, nil, 0, 6, 6},
+				{Dot, nil, 7, 8, 7},
+				{ArrayOpen, nil, 8, 9, 8},
+				{ArrayClose, nil, 9, 10, 9},
+				{As, nil, 11, 13, 13},
+				{Variable, nil, 14, 16, 16},
+				{ParenOpen, nil, 17, 18, 17},
+				{Integer, nil, 18, 19, 19},
+				{Semicolon, nil, 19, 20, 19},
+				{Dot, nil, 21, 22, 21},
+				{ParenClose, nil, 22, 23, 22},
+				{Comma, nil, 23, 24, 23},
+				{Foreach, nil, 25, 32, 32},
+			},
+		},
+		{
+			name:             "alternative patterns",
+			query:            ". as [$a] ?// $a | .?/2",
+			ignoreWhitespace: true,
+			want: []Token{
+				{Dot, nil, 0, 1, 0},
+				{As, nil, 2, 4, 4},
+				{ArrayOpen, nil, 5, 6, 5},
+				{Variable, nil, 6, 8, 8},
+				{ArrayClose, nil, 8, 9, 8},
+				{Alternative, nil, 10, 13, 10},
+				{Variable, nil, 14, 16, 16},
+				{Pipe, nil, 17, 18, 17},
+				{Dot, nil, 19, 20, 19},
+				{Question, nil, 20, 21, 20},
+				{Slash, nil, 21, 22, 21},
+				{Integer, nil, 22, 23, 23},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
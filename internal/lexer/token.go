@@ -17,9 +17,22 @@ const (
 	// Integer represents an integer token type.
 	Integer
 
+	// Float represents a floating-point number token type.
+	Float
+
+	// Boolean represents a boolean token type.
+	Boolean
+
+	// DateTime represents a date, a local date-time or an offset date-time
+	// token type.
+	DateTime
+
 	// Dot represents a full stop token type.
 	Dot
 
+	// DoubleDot represents a double full stop token type.
+	DoubleDot
+
 	// Colon represents a colon token type.
 	Colon
 
@@ -29,6 +42,104 @@ const (
 	// ArrayClose represents a closing bracket token type.
 	ArrayClose
 
+	// Pipe represents a vertical bar token type.
+	Pipe
+
+	// Comma represents a comma token type.
+	Comma
+
+	// Question represents a question mark token type.
+	Question
+
+	// ObjectOpen represents an opening brace token type.
+	ObjectOpen
+
+	// ObjectClose represents a closing brace token type.
+	ObjectClose
+
+	// Semicolon represents a semicolon token type.
+	Semicolon
+
+	// ParenOpen represents an opening parenthesis token type.
+	ParenOpen
+
+	// ParenClose represents a closing parenthesis token type.
+	ParenClose
+
+	// Plus represents an addition operator token type.
+	Plus
+
+	// Minus represents a subtraction operator token type.
+	Minus
+
+	// Star represents a multiplication operator token type.
+	Star
+
+	// Slash represents a division operator token type.
+	Slash
+
+	// Percent represents a modulo operator token type.
+	Percent
+
+	// Equal represents an equality operator token type.
+	Equal
+
+	// NotEqual represents an inequality operator token type.
+	NotEqual
+
+	// Less represents a less-than operator token type.
+	Less
+
+	// LessEqual represents a less-than-or-equal operator token type.
+	LessEqual
+
+	// Greater represents a greater-than operator token type.
+	Greater
+
+	// GreaterEqual represents a greater-than-or-equal operator token type.
+	GreaterEqual
+
+	// And represents a logical conjunction keyword token type.
+	And
+
+	// Or represents a logical disjunction keyword token type.
+	Or
+
+	// InterpolationStart represents the part of a quoted string from the
+	// opening quote up to the first interpolated expression.
+	InterpolationStart
+
+	// InterpolationMiddle represents the part of a quoted string between two
+	// interpolated expressions.
+	InterpolationMiddle
+
+	// InterpolationEnd represents the part of a quoted string from the last
+	// interpolated expression up to the closing quote.
+	InterpolationEnd
+
+	// Format represents a format string token type such as @csv.
+	Format
+
+	// Variable represents a variable reference token type such as $name.
+	Variable
+
+	// As represents a variable binding keyword token type.
+	As
+
+	// Alternative represents the ?// token type separating alternative
+	// destructuring patterns.
+	Alternative
+
+	// Def represents a function definition keyword token type.
+	Def
+
+	// Reduce represents a reduction keyword token type.
+	Reduce
+
+	// Foreach represents a keyword token type of the reduction producing
+	// intermediate states.
+	Foreach
+
 	// Whitespace represents a white space token type.
 	Whitespace
 )
@@ -39,6 +150,44 @@ var keyCharMap = map[rune]TokenType{
 	':': Colon,
 	'[': ArrayOpen,
 	']': ArrayClose,
+	'|': Pipe,
+	',': Comma,
+	'?': Question,
+	'{': ObjectOpen,
+	'}': ObjectClose,
+	';': Semicolon,
+	'(': ParenOpen,
+	')': ParenClose,
+	'+': Plus,
+	'*': Star,
+	'/': Slash,
+	'%': Percent,
+	'<': Less,
+	'>': Greater,
+}
+
+// operatorMap maps two-character operators onto TokenTypes.
+var operatorMap = map[string]TokenType{
+	"..": DoubleDot,
+	"==": Equal,
+	"!=": NotEqual,
+	"<=": LessEqual,
+	">=": GreaterEqual,
+}
+
+// keywordMap maps reserved bare words onto TokenTypes.
+var keywordMap = map[string]TokenType{
+	"and":     And,
+	"or":      Or,
+	"as":      As,
+	"def":     Def,
+	"reduce":  Reduce,
+	"foreach": Foreach,
+	"true":    Boolean,
+	"false":   Boolean,
+	"inf":     Float,
+	"-inf":    Float,
+	"nan":     Float,
 }
 
 // escapeSequenceMap maps popular escape sequence characters onto its Go string
@@ -57,6 +206,19 @@ var escapeSequenceMap = map[rune]string{
 // TokenType indicates the type of the lexer Token.
 type TokenType uint8
 
+// endsOperand reports if the token of type t may close an operand, in which
+// case a minus sign following it stands for the subtraction operator.
+func (t TokenType) endsOperand() bool {
+	switch t {
+	case String, Integer, Float, Boolean, DateTime, Dot, DoubleDot,
+		ArrayClose, ObjectClose, ParenClose, Question, InterpolationEnd,
+		Format, Variable:
+		return true
+	default:
+		return false
+	}
+}
+
 // Token represents a single lexeme read from the Scanner token buffer.
 type Token struct {
 	Type                   TokenType
@@ -64,6 +226,35 @@ type Token struct {
 	Start, End, LineOffset int
 }
 
+// Quoted reports if the Token is a quoted string token.
+func (t Token) Quoted() bool {
+	if t.Type != String || t.Buffer == nil || t.Start >= len(*t.Buffer) {
+		return false
+	}
+	return isQuote((*t.Buffer)[t.Start].Rune)
+}
+
+// Keyword reports if the Token is a reserved bare word.
+func (t Token) Keyword() bool {
+	tp, ok := keywordMap[t.Lexeme()]
+	return ok && tp == t.Type
+}
+
+// Line returns the number of the query line where the Token starts counting
+// from one.
+func (t Token) Line() int {
+	result := 1
+	if t.Buffer == nil {
+		return result
+	}
+	for _, c := range (*t.Buffer)[:min(t.Start, len(*t.Buffer))] {
+		if isLineBreak(c.Rune) {
+			result++
+		}
+	}
+	return result
+}
+
 // Lexeme returns the string representation of the Token.
 func (t Token) Lexeme() string {
 	var result string
@@ -71,7 +262,7 @@ func (t Token) Lexeme() string {
 		return result
 	}
 	switch t.Type {
-	case String:
+	case String, InterpolationStart, InterpolationMiddle, InterpolationEnd:
 		result = t.reprString()
 	default:
 		result = t.reprDefault()
@@ -79,35 +270,46 @@ func (t Token) Lexeme() string {
 	return result
 }
 
+// reprString returns the string with escape sequences replaced with the
+// characters they stand for. Quotes of quoted strings and delimiters of
+// interpolated expressions are left out.
 func (t Token) reprString() string {
-	end := t.End
-	head := t.Start
-	size := t.End - t.Start
+	head, end := t.Start, t.End
 	if end > len(*t.Buffer) {
 		end = len(*t.Buffer)
 	}
-	chars := make([]string, 0, size)
-	for head != end {
+	switch t.Type {
+	case InterpolationStart, InterpolationMiddle:
+		head, end = head+1, end-2
+	case InterpolationEnd:
+		head, end = head+1, end-1
+	default:
+		if isQuote((*t.Buffer)[head].Rune) {
+			head, end = head+1, end-1
+		}
+	}
+	chars := make([]string, 0, max(end-head, 0))
+	for head < end {
 		// NOTE: For quoted strings, check if the current token initiates an
-		// escape sequence and there is at least a single token left to look up
-		// followed by the terminating quote character. Bare strings may not
-		// contain escape sequence characters, because forward slash is a
-		// disallowed character in bare strings.
+		// escape sequence and there are enough tokens left to look up before
+		// the terminating quote character. Bare strings may not contain
+		// escape sequence characters, because forward slash is a disallowed
+		// character in bare strings.
 		token := (*t.Buffer)[head]
-		if token.Rune == '\\' && head+1 != end {
+		if token.Rune == '\\' && head+1 < end {
 			v, ok := escapeSequenceMap[(*t.Buffer)[head+1].Rune]
 			if ok {
 				head += 2
 				chars = append(chars, v)
 				continue
 			}
-			if (*t.Buffer)[head+1].Rune == 'u' && head+5 != end {
+			if (*t.Buffer)[head+1].Rune == 'u' && head+6 <= end {
 				char := t.parseUnicode(head, 2, 6)
 				head += 6
 				chars = append(chars, char)
 				continue
 			}
-			if (*t.Buffer)[head+1].Rune == 'U' && head+9 != end {
+			if (*t.Buffer)[head+1].Rune == 'U' && head+10 <= end {
 				char := t.parseUnicode(head, 2, 10)
 				head += 10
 				chars = append(chars, char)
@@ -117,9 +319,6 @@ func (t Token) reprString() string {
 		chars = append(chars, string(token.Rune))
 		head++
 	}
-	if chars[0] == "'" || chars[0] == "\"" {
-		chars = chars[1 : len(chars)-1]
-	}
 	return strings.Join(chars, "")
 }
 
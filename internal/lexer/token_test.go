@@ -1,6 +1,7 @@
 package lexer
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/mdm-code/scanner"
@@ -201,6 +202,50 @@ func TestLexeme(t *testing.T) {
 			},
 			want: "😱🙏",
 		},
+		{
+			name: "interpolation-start",
+			token: Token{
+				Buffer: &[]scanner.Token{
+					{Pos: scanner.Pos{Rune: '"'}, Buffer: nil},
+					{Pos: scanner.Pos{Rune: 'a'}, Buffer: nil},
+					{Pos: scanner.Pos{Rune: '\\'}, Buffer: nil},
+					{Pos: scanner.Pos{Rune: '('}, Buffer: nil},
+				},
+				Type:  InterpolationStart,
+				Start: 0,
+				End:   4,
+			},
+			want: "a",
+		},
+		{
+			name: "interpolation-middle",
+			token: Token{
+				Buffer: &[]scanner.Token{
+					{Pos: scanner.Pos{Rune: ')'}, Buffer: nil},
+					{Pos: scanner.Pos{Rune: '\\'}, Buffer: nil},
+					{Pos: scanner.Pos{Rune: 't'}, Buffer: nil},
+					{Pos: scanner.Pos{Rune: '\\'}, Buffer: nil},
+					{Pos: scanner.Pos{Rune: '('}, Buffer: nil},
+				},
+				Type:  InterpolationMiddle,
+				Start: 0,
+				End:   5,
+			},
+			want: "\t",
+		},
+		{
+			name: "interpolation-end",
+			token: Token{
+				Buffer: &[]scanner.Token{
+					{Pos: scanner.Pos{Rune: ')'}, Buffer: nil},
+					{Pos: scanner.Pos{Rune: '"'}, Buffer: nil},
+				},
+				Type:  InterpolationEnd,
+				Start: 0,
+				End:   2,
+			},
+			want: "",
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -210,3 +255,122 @@ func TestLexeme(t *testing.T) {
 		})
 	}
 }
+
+// Check if Line counts line breaks preceding the token.
+func TestLine(t *testing.T) {
+	buf := &[]scanner.Token{
+		{Pos: scanner.Pos{Rune: '.'}, Buffer: nil},
+		{Pos: scanner.Pos{Rune: '\n'}, Buffer: nil},
+		{Pos: scanner.Pos{Rune: '|'}, Buffer: nil},
+		{Pos: scanner.Pos{Rune: '\n'}, Buffer: nil},
+		{Pos: scanner.Pos{Rune: '.'}, Buffer: nil},
+	}
+	cases := []struct {
+		name  string
+		token Token
+		want  int
+	}{
+		{"nil-buffer", Token{Type: Dot, Start: 4, End: 5}, 1},
+		{"first-line", Token{Buffer: buf, Type: Dot, Start: 0, End: 1}, 1},
+		{"second-line", Token{Buffer: buf, Type: Pipe, Start: 2, End: 3}, 2},
+		{"third-line", Token{Buffer: buf, Type: Dot, Start: 4, End: 5}, 3},
+		{"past-buffer", Token{Buffer: buf, Type: Dot, Start: 9, End: 10}, 3},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if have := c.token.Line(); have != c.want {
+				t.Errorf("want: %d; have: %d", c.want, have)
+			}
+		})
+	}
+}
+
+// Verify if quoted string tokens are told apart from other tokens.
+func TestQuoted(t *testing.T) {
+	buffer := &[]scanner.Token{
+		{Pos: scanner.Pos{Rune: '\''}, Buffer: nil},
+		{Pos: scanner.Pos{Rune: 'a'}, Buffer: nil},
+		{Pos: scanner.Pos{Rune: '\''}, Buffer: nil},
+		{Pos: scanner.Pos{Rune: '1'}, Buffer: nil},
+	}
+	cases := []struct {
+		name  string
+		token Token
+		want  bool
+	}{
+		{"quoted", Token{Type: String, Buffer: buffer, Start: 0, End: 3}, true},
+		{"bare", Token{Type: String, Buffer: buffer, Start: 1, End: 2}, false},
+		{"integer", Token{Type: Integer, Buffer: buffer, Start: 3, End: 4}, false},
+		{"nil-buffer", Token{Type: String}, false},
+		{"out-of-range", Token{Type: String, Buffer: buffer, Start: 4, End: 5}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if have := c.token.Quoted(); have != c.want {
+				t.Errorf("want: %t; have: %t", c.want, have)
+			}
+		})
+	}
+}
+
+// Verify if keyword tokens are reported as reserved bare words.
+func TestKeyword(t *testing.T) {
+	buffer := &[]scanner.Token{
+		{Pos: scanner.Pos{Rune: 'o'}, Buffer: nil},
+		{Pos: scanner.Pos{Rune: 'r'}, Buffer: nil},
+	}
+	cases := []struct {
+		name  string
+		token Token
+		want  bool
+	}{
+		{"keyword", Token{Type: Or, Buffer: buffer, Start: 0, End: 2}, true},
+		{"string", Token{Type: String, Buffer: buffer, Start: 0, End: 2}, false},
+		{"other", Token{Type: Or, Buffer: buffer, Start: 0, End: 1}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if have := c.token.Keyword(); have != c.want {
+				t.Errorf("want: %t; have: %t", c.want, have)
+			}
+		})
+	}
+}
+
+// Check if only tokens that may close an operand turn the minus sign into the
+// subtraction operator.
+func TestEndsOperand(t *testing.T) {
+	cases := []struct {
+		tp   TokenType
+		want bool
+	}{
+		{String, true},
+		{Integer, true},
+		{Dot, true},
+		{ArrayClose, true},
+		{ObjectClose, true},
+		{ParenClose, true},
+		{InterpolationEnd, true},
+		{Variable, true},
+		{As, false},
+		{Alternative, false},
+		{Def, false},
+		{Reduce, false},
+		{Foreach, false},
+		{Undefined, false},
+		{InterpolationStart, false},
+		{InterpolationMiddle, false},
+		{ArrayOpen, false},
+		{Colon, false},
+		{Pipe, false},
+		{Equal, false},
+		{Plus, false},
+	}
+	for _, c := range cases {
+		t.Run(strconv.Itoa(int(c.tp)), func(t *testing.T) {
+			if have := c.tp.endsOperand(); have != c.want {
+				t.Errorf("want: %t; have: %t", c.want, have)
+			}
+		})
+	}
+}
@@ -14,17 +14,46 @@ func isDigit(r rune) bool {
 	return unicode.IsDigit(r)
 }
 
+// isMinus verifies if the rune r is a minus sign character.
+func isMinus(r rune) bool {
+	return r == '-'
+}
+
 // isKeyChar verifies if the rune r is a key character.
 func isKeyChar(r rune) bool {
 	_, ok := keyCharMap[r]
 	return ok
 }
 
+// isOperator verifies if the runes r and next make up a two-character
+// operator.
+func isOperator(r, next rune) bool {
+	_, ok := operatorMap[string([]rune{r, next})]
+	return ok
+}
+
 // isQuote verifies if the rune r is a quote character.
 func isQuote(r rune) bool {
 	return r == '"' || r == '\''
 }
 
+// isFormat verifies if the rune r is the character opening a format string.
+func isFormat(r rune) bool {
+	return r == '@'
+}
+
+// isVariable verifies if the rune r is the character opening a variable
+// reference.
+func isVariable(r rune) bool {
+	return r == '$'
+}
+
+// isNameChar checks if the rune r may appear in the name of a variable. Names
+// do not start with a digit.
+func isNameChar(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
 // isBareChar checks if the rune r is an accepted TOML bare key character.
 func isBareChar(r rune) bool {
 	if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
@@ -63,6 +63,28 @@ func TestIsDigit(t *testing.T) {
 	}
 }
 
+// Check if minus sign characters are correctly identified.
+func TestIsMinus(t *testing.T) {
+	cases := []struct {
+		input rune
+		want  bool
+		name  string
+	}{
+		{'-', true, "-"},
+		{'_', false, "_"},
+		{'0', false, "0"},
+		{'a', false, "a"},
+		{' ', false, " "},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if have := isMinus(c.input); have != c.want {
+				t.Errorf("want: %t; have: %t", c.want, have)
+			}
+		})
+	}
+}
+
 // Test if key characters are correctly identified.
 func TestIsKeyChar(t *testing.T) {
 	cases := []struct {
@@ -74,6 +96,21 @@ func TestIsKeyChar(t *testing.T) {
 		{':', true, ":"},
 		{'[', true, "["},
 		{']', true, "]"},
+		{'|', true, "|"},
+		{',', true, ","},
+		{'?', true, "?"},
+		{'<', true, "<"},
+		{'>', true, ">"},
+		{'{', true, "{"},
+		{'}', true, "}"},
+		{';', true, ";"},
+		{'(', true, "("},
+		{')', true, ")"},
+		{'+', true, "+"},
+		{'*', true, "*"},
+		{'/', true, "/"},
+		{'%', true, "%"},
+		{'-', false, "-"},
 		{'\t', false, "\\t"},
 		{' ', false, " "},
 		{'\r', false, "\\r"},
@@ -92,6 +129,32 @@ func TestIsKeyChar(t *testing.T) {
 	}
 }
 
+// Verify if two-character operators are correctly identified.
+func TestIsOperator(t *testing.T) {
+	cases := []struct {
+		r, next rune
+		want    bool
+		name    string
+	}{
+		{'.', '.', true, ".."},
+		{'=', '=', true, "=="},
+		{'!', '=', true, "!="},
+		{'<', '=', true, "<="},
+		{'>', '=', true, ">="},
+		{'.', 'a', false, ".a"},
+		{'<', ' ', false, "< "},
+		{'=', 0, false, "="},
+		{'!', 0, false, "!"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if have := isOperator(c.r, c.next); have != c.want {
+				t.Errorf("want: %t; have: %t", c.want, have)
+			}
+		})
+	}
+}
+
 // Check if quote characters are correctly identified.
 func TestIsQuote(t *testing.T) {
 	cases := []struct {
@@ -120,3 +183,69 @@ func TestIsQuote(t *testing.T) {
 		})
 	}
 }
+
+// Check if the character opening format strings is correctly identified.
+func TestIsFormat(t *testing.T) {
+	cases := []struct {
+		input rune
+		want  bool
+		name  string
+	}{
+		{'@', true, "@"},
+		{'$', false, "$"},
+		{'a', false, "a"},
+		{'"', false, "\""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if have := isFormat(c.input); have != c.want {
+				t.Errorf("want: %t; have: %t", c.want, have)
+			}
+		})
+	}
+}
+
+// Check if the character opening variable references is correctly identified.
+func TestIsVariable(t *testing.T) {
+	cases := []struct {
+		input rune
+		want  bool
+		name  string
+	}{
+		{'$', true, "$"},
+		{'@', false, "@"},
+		{'a', false, "a"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if have := isVariable(c.input); have != c.want {
+				t.Errorf("want: %t; have: %t", c.want, have)
+			}
+		})
+	}
+}
+
+// Check if characters of variable names are correctly identified.
+func TestIsNameChar(t *testing.T) {
+	cases := []struct {
+		input rune
+		want  bool
+		name  string
+	}{
+		{'a', true, "a"},
+		{'Z', true, "Z"},
+		{'0', true, "0"},
+		{'_', true, "_"},
+		{'-', false, "-"},
+		{'.', false, "."},
+		{'$', false, "$"},
+		{'ł', false, "ł"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if have := isNameChar(c.input); have != c.want {
+				t.Errorf("want: %t; have: %t", c.want, have)
+			}
+		})
+	}
+}
@@ -15,6 +15,57 @@ var (
 	// ErrSelectorUnterminated indicates an unterminated selector element.
 	ErrSelectorUnterminated = errors.New("expected ']' to terminate selector")
 
+	// ErrArrayUnterminated indicates an unterminated array construction.
+	ErrArrayUnterminated = errors.New("expected ']' to terminate array")
+
+	// ErrObjectUnterminated indicates an unterminated object construction.
+	ErrObjectUnterminated = errors.New("expected '}' to terminate object")
+
+	// ErrParenUnterminated indicates an unterminated parenthesized expression.
+	ErrParenUnterminated = errors.New("expected ')' to terminate expression")
+
+	// ErrInterpolationUnterminated indicates an interpolated expression that
+	// is not followed by the rest of the quoted string.
+	ErrInterpolationUnterminated = errors.New("expected ')' to terminate interpolation")
+
+	// ErrBindingPattern indicates a binding without the pattern to bind
+	// values to.
+	ErrBindingPattern = errors.New("expected variable or pattern to bind values to")
+
+	// ErrBindingBody indicates a binding pattern not followed by the body.
+	ErrBindingBody = errors.New("expected '|' to follow the binding pattern")
+
+	// ErrFoldSource indicates a reduction source not followed by the binding.
+	ErrFoldSource = errors.New("expected 'as' to follow the reduction source")
+
+	// ErrFoldState indicates a reduction pattern not followed by the initial
+	// state in parentheses.
+	ErrFoldState = errors.New("expected '(' to follow the reduction pattern")
+
+	// ErrFoldUpdate indicates a reduction initial state not followed by the
+	// update expression.
+	ErrFoldUpdate = errors.New("expected ';' to follow the initial state")
+
+	// ErrDefinitionName indicates a function definition without the name.
+	ErrDefinitionName = errors.New("expected function name to follow 'def'")
+
+	// ErrDefinitionParam indicates a function parameter that is neither a
+	// bare string nor a variable.
+	ErrDefinitionParam = errors.New("expected function parameter name")
+
+	// ErrDefinitionBody indicates a function signature not followed by the
+	// body of the function.
+	ErrDefinitionBody = errors.New("expected ':' to follow the function signature")
+
+	// ErrDefinitionUnterminated indicates an unterminated function body.
+	ErrDefinitionUnterminated = errors.New("expected ';' to terminate function definition")
+
+	// ErrSpanStep indicates a span with the step equal to zero.
+	ErrSpanStep = errors.New("span step cannot be zero")
+
+	// ErrLiteral indicates a literal value that cannot be represented.
+	ErrLiteral = errors.New("malformed literal value")
+
 	// ErrParserBufferOutOfRange indicates the end of the parser buffer has
 	// been reached.
 	ErrParserBufferOutOfRange = errors.New("reached the end of the buffer")
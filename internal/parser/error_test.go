@@ -97,6 +97,9 @@ func TestErrorIs(t *testing.T) {
 	}{
 		{name: "ErrQueryElement", want: ErrQueryElement},
 		{name: "ErrSelectorUnterminated", want: ErrSelectorUnterminated},
+		{name: "ErrParenUnterminated", want: ErrParenUnterminated},
+		{name: "ErrSpanStep", want: ErrSpanStep},
+		{name: "ErrLiteral", want: ErrLiteral},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
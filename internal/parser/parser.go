@@ -2,9 +2,14 @@ package parser
 
 import (
 	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/mdm-code/tq/v2/internal/ast"
 	"github.com/mdm-code/tq/v2/internal/lexer"
+	"github.com/pelletier/go-toml/v2"
 )
 
 // Parser encapsulates the logic of parsing tq queries into valid expressions.
@@ -35,17 +40,368 @@ func (p *Parser) Parse() (*ast.Root, error) {
 }
 
 func (p *Parser) root() (ast.Root, error) {
+	if p.isAtEnd() {
+		return ast.Root{Query: &ast.Query{}}, nil
+	}
+	q, err := p.pipe()
+	expr := ast.Root{Query: q}
+	if err == nil && !p.isAtEnd() {
+		err = p.errorAtPeek(ErrQueryElement)
+	}
+	return expr, err
+}
+
+func (p *Parser) pipe() (ast.Expr, error) {
+	if p.match(lexer.Def) {
+		return p.definition()
+	}
+	left, err := p.comma()
+	if err != nil || !p.match(lexer.Pipe) {
+		return left, err
+	}
+	right, err := p.pipe()
+	expr := ast.Pipe{Left: left, Right: right}
+	return &expr, err
+}
+
+// definition parses the function definition followed by the expression that
+// the function is visible in. Parameters are separated with semicolons, and
+// the body of the function is terminated with a semicolon.
+func (p *Parser) definition() (ast.Expr, error) {
+	var expr ast.Definition
+	if !p.checkBare() {
+		return &expr, p.errorAtPeek(ErrDefinitionName)
+	}
+	expr.Name = p.advance().Lexeme()
+	if p.match(lexer.ParenOpen) {
+		for {
+			switch {
+			case p.match(lexer.Variable):
+				expr.Params = append(expr.Params, p.previous().Lexeme())
+			case p.checkBare():
+				expr.Params = append(expr.Params, p.advance().Lexeme())
+			default:
+				return &expr, p.errorAtPeek(ErrDefinitionParam)
+			}
+			if !p.match(lexer.Semicolon) {
+				break
+			}
+		}
+		_, err := p.consume(lexer.ParenClose, ErrParenUnterminated)
+		if err != nil {
+			return &expr, err
+		}
+	}
+	_, err := p.consume(lexer.Colon, ErrDefinitionBody)
+	if err != nil {
+		return &expr, err
+	}
+	expr.Body, err = p.pipe()
+	if err != nil {
+		return &expr, err
+	}
+	_, err = p.consume(lexer.Semicolon, ErrDefinitionUnterminated)
+	if err != nil {
+		return &expr, err
+	}
+	expr.Rest, err = p.pipe()
+	return &expr, err
+}
+
+func (p *Parser) comma() (ast.Expr, error) {
+	expr, err := p.or()
+	for err == nil && p.match(lexer.Comma) {
+		var right ast.Expr
+		right, err = p.or()
+		expr = &ast.Comma{Left: expr, Right: right}
+	}
+	return expr, err
+}
+
+func (p *Parser) or() (ast.Expr, error) {
+	expr, err := p.and()
+	for err == nil && p.match(lexer.Or) {
+		operator := p.previous().Lexeme()
+		var right ast.Expr
+		right, err = p.and()
+		expr = &ast.Logical{Left: expr, Operator: operator, Right: right}
+	}
+	return expr, err
+}
+
+func (p *Parser) and() (ast.Expr, error) {
+	expr, err := p.comparison()
+	for err == nil && p.match(lexer.And) {
+		operator := p.previous().Lexeme()
+		var right ast.Expr
+		right, err = p.comparison()
+		expr = &ast.Logical{Left: expr, Operator: operator, Right: right}
+	}
+	return expr, err
+}
+
+func (p *Parser) comparison() (ast.Expr, error) {
+	expr, err := p.additive()
+	if err == nil && p.match(
+		lexer.Equal,
+		lexer.NotEqual,
+		lexer.Less,
+		lexer.LessEqual,
+		lexer.Greater,
+		lexer.GreaterEqual,
+	) {
+		operator := p.previous().Lexeme()
+		var right ast.Expr
+		right, err = p.additive()
+		expr = &ast.Binary{Left: expr, Operator: operator, Right: right}
+	}
+	return expr, err
+}
+
+func (p *Parser) additive() (ast.Expr, error) {
+	expr, err := p.multiplicative()
+	for err == nil && p.match(lexer.Plus, lexer.Minus) {
+		operator := p.previous().Lexeme()
+		var right ast.Expr
+		right, err = p.multiplicative()
+		expr = &ast.Binary{Left: expr, Operator: operator, Right: right}
+	}
+	return expr, err
+}
+
+func (p *Parser) multiplicative() (ast.Expr, error) {
+	expr, err := p.negation()
+	for err == nil && p.match(lexer.Star, lexer.Slash, lexer.Percent) {
+		operator := p.previous().Lexeme()
+		var right ast.Expr
+		right, err = p.negation()
+		expr = &ast.Binary{Left: expr, Operator: operator, Right: right}
+	}
+	return expr, err
+}
+
+// negation parses the minus sign in front of the operand. Minus signs in front
+// of numbers are part of number literals, so this covers other operands such
+// as `-.a` or `-(.a + 1)`.
+func (p *Parser) negation() (ast.Expr, error) {
+	if !p.match(lexer.Minus) {
+		return p.binding()
+	}
+	var expr ast.Negation
+	var err error
+	expr.Value, err = p.negation()
+	return &expr, err
+}
+
+// binding parses the query optionally followed by the binding of its outputs
+// to the pattern. The body of the binding extends as far to the right as
+// possible, so the binding has the lowest precedence of all expressions.
+func (p *Parser) binding() (ast.Expr, error) {
+	q, err := p.query()
+	if err != nil || !p.match(lexer.As) {
+		return &q, err
+	}
+	expr := ast.Binding{Source: &q}
+	expr.Patterns, err = p.patterns()
+	if err != nil {
+		return &expr, err
+	}
+	_, err = p.consume(lexer.Pipe, ErrBindingBody)
+	if err != nil {
+		return &expr, err
+	}
+	expr.Body, err = p.pipe()
+	return &expr, err
+}
+
+// patterns parses alternative patterns separated with ?//.
+func (p *Parser) patterns() ([]ast.Expr, error) {
+	var result []ast.Expr
+	for {
+		pattern, err := p.pattern()
+		if err != nil {
+			return result, err
+		}
+		result = append(result, pattern)
+		if !p.match(lexer.Alternative) {
+			return result, nil
+		}
+	}
+}
+
+// reduce parses the reduction with the initial state and the update
+// expression in parentheses.
+func (p *Parser) reduce() (*ast.Reduce, error) {
+	var expr ast.Reduce
+	var err error
+	expr.Source, expr.Patterns, err = p.fold()
+	if err == nil {
+		expr.Init, err = p.pipe()
+	}
+	if err == nil {
+		_, err = p.consume(lexer.Semicolon, ErrFoldUpdate)
+	}
+	if err == nil {
+		expr.Update, err = p.pipe()
+	}
+	if err == nil {
+		_, err = p.consume(lexer.ParenClose, ErrParenUnterminated)
+	}
+	return &expr, err
+}
+
+// foreach parses the reduction producing intermediate states. The extract
+// expression following the update expression may be omitted.
+func (p *Parser) foreach() (*ast.Foreach, error) {
+	var expr ast.Foreach
+	var err error
+	expr.Source, expr.Patterns, err = p.fold()
+	if err == nil {
+		expr.Init, err = p.pipe()
+	}
+	if err == nil {
+		_, err = p.consume(lexer.Semicolon, ErrFoldUpdate)
+	}
+	if err == nil {
+		expr.Update, err = p.pipe()
+	}
+	if err == nil && p.match(lexer.Semicolon) {
+		expr.Extract, err = p.pipe()
+	}
+	if err == nil {
+		_, err = p.consume(lexer.ParenClose, ErrParenUnterminated)
+	}
+	return &expr, err
+}
+
+// fold parses the source query of the reduction and the patterns its outputs
+// are bound to up to the opening parenthesis.
+func (p *Parser) fold() (ast.Expr, []ast.Expr, error) {
 	q, err := p.query()
-	expr := ast.Root{Query: &q}
+	if err != nil {
+		return &q, nil, err
+	}
+	_, err = p.consume(lexer.As, ErrFoldSource)
+	if err != nil {
+		return &q, nil, err
+	}
+	patterns, err := p.patterns()
+	if err != nil {
+		return &q, patterns, err
+	}
+	_, err = p.consume(lexer.ParenOpen, ErrFoldState)
+	return &q, patterns, err
+}
+
+// pattern parses the pattern that outputs of the binding source are bound to.
+// Besides a single variable, array and object patterns destructure outputs
+// into variables bound to their parts.
+func (p *Parser) pattern() (ast.Expr, error) {
+	switch {
+	case p.match(lexer.Variable):
+		return &ast.Variable{Name: variableName(p.previous())}, nil
+	case p.match(lexer.ArrayOpen):
+		return p.arrayPattern()
+	case p.match(lexer.ObjectOpen):
+		return p.objectPattern()
+	default:
+		return nil, p.errorAtPeek(ErrBindingPattern)
+	}
+}
+
+func (p *Parser) arrayPattern() (*ast.ArrayPattern, error) {
+	var expr ast.ArrayPattern
+	for {
+		e, err := p.pattern()
+		if err != nil {
+			return &expr, err
+		}
+		expr.Elements = append(expr.Elements, e)
+		if !p.match(lexer.Comma) {
+			break
+		}
+	}
+	_, err := p.consume(lexer.ArrayClose, ErrArrayUnterminated)
+	return &expr, err
+}
+
+func (p *Parser) objectPattern() (*ast.ObjectPattern, error) {
+	var expr ast.ObjectPattern
+	for {
+		e, err := p.objectPatternEntry()
+		if err != nil {
+			return &expr, err
+		}
+		expr.Entries = append(expr.Entries, e)
+		if !p.match(lexer.Comma) {
+			break
+		}
+	}
+	_, err := p.consume(lexer.ObjectClose, ErrObjectUnterminated)
+	return &expr, err
+}
+
+// objectPatternEntry parses a single entry of the object pattern. The key is
+// written the way it is in the object construction, and it is followed by the
+// pattern for the value. The variable standing for the key may omit it.
+func (p *Parser) objectPatternEntry() (ast.ObjectEntry, error) {
+	var expr ast.ObjectEntry
+	var err error
+	switch {
+	case p.match(lexer.Variable):
+		expr.Key = &ast.Variable{Name: variableName(p.previous())}
+		if !p.match(lexer.Colon) {
+			return expr, nil
+		}
+		expr.Value, err = p.pattern()
+		return expr, err
+	case p.match(lexer.ParenOpen):
+		expr.Key, err = p.group()
+	case p.match(lexer.InterpolationStart):
+		var i ast.Interpolation
+		i, err = p.interpolation()
+		expr.Key = &i
+	case p.checkObjectKey():
+		expr.Key = &ast.Literal{Value: p.advance().Lexeme()}
+	default:
+		return expr, p.errorAtPeek(ErrBindingPattern)
+	}
+	if err == nil {
+		_, err = p.consume(lexer.Colon, ErrQueryElement)
+	}
+	if err == nil {
+		expr.Value, err = p.pattern()
+	}
 	return expr, err
 }
 
+// variableName returns the name of the variable without the $ character.
+func variableName(t lexer.Token) string {
+	return strings.TrimPrefix(t.Lexeme(), "$")
+}
+
 func (p *Parser) query() (ast.Query, error) {
 	var expr ast.Query
 	var err error
-	for !p.isAtEnd() {
+	if p.checkTerm() {
+		var f ast.Filter
+		f, err = p.term()
+		for err == nil && p.match(lexer.Question) {
+			f.Kind = &ast.Optional{Value: f.Kind}
+		}
+		expr.Filters = append(expr.Filters, &f)
+		if err != nil {
+			return expr, err
+		}
+	} else if !p.checkFilter() {
+		return expr, p.errorAtPeek(ErrQueryElement)
+	}
+	for p.checkFilter() {
 		var f ast.Filter
 		f, err = p.filter()
+		for err == nil && p.match(lexer.Question) {
+			f.Kind = &ast.Optional{Value: f.Kind}
+		}
 		expr.Filters = append(expr.Filters, &f)
 		if err != nil {
 			break
@@ -54,14 +410,73 @@ func (p *Parser) query() (ast.Query, error) {
 	return expr, err
 }
 
+// term parses the filter elements that may only open a query.
+func (p *Parser) term() (ast.Filter, error) {
+	var expr ast.Filter
+	var err error
+	switch {
+	case p.checkBare():
+		root := p.current == 0
+		p.advance()
+		var c ast.Call
+		c, err = p.call()
+		c.Root = root
+		expr.Kind = &c
+	case p.match(
+		lexer.Integer,
+		lexer.Float,
+		lexer.Boolean,
+		lexer.DateTime,
+		lexer.String,
+	):
+		var l ast.Literal
+		l, err = p.literal()
+		expr.Kind = &l
+	case p.match(lexer.InterpolationStart):
+		var i ast.Interpolation
+		i, err = p.interpolation()
+		expr.Kind = &i
+	case p.match(lexer.Format):
+		expr.Kind, err = p.format()
+	case p.match(lexer.Variable):
+		expr.Kind = p.variable()
+	case p.match(lexer.Reduce):
+		expr.Kind, err = p.reduce()
+	case p.match(lexer.Foreach):
+		expr.Kind, err = p.foreach()
+	case p.match(lexer.ParenOpen):
+		expr.Kind, err = p.group()
+	case p.match(lexer.ObjectOpen):
+		var o ast.Object
+		o, err = p.object()
+		expr.Kind = &o
+	case p.match(lexer.ArrayOpen):
+		var a ast.Array
+		a, err = p.array()
+		expr.Kind = &a
+	default:
+		err = p.errorAtPeek(ErrQueryElement)
+	}
+	return expr, err
+}
+
 func (p *Parser) filter() (ast.Filter, error) {
 	var expr ast.Filter
 	var err error
 	switch {
+	case p.checkKey():
+		p.advance()
+		var s ast.String
+		s, err = p.string()
+		expr.Kind = &s
 	case p.match(lexer.Dot):
 		var i ast.Identity
 		i, err = p.identity()
 		expr.Kind = &i
+	case p.match(lexer.DoubleDot):
+		var r ast.Recurse
+		r, err = p.recurse()
+		expr.Kind = &r
 	case p.match(lexer.ArrayOpen):
 		var s ast.Selector
 		s, err = p.selector()
@@ -71,8 +486,7 @@ func (p *Parser) filter() (ast.Filter, error) {
 		s, err = p.string()
 		expr.Kind = &s
 	default:
-		v, _ := p.peek()
-		err = &Error{v.Lexeme(), v.Buffer, v.Start, v.LineOffset, ErrQueryElement}
+		err = p.errorAtPeek(ErrQueryElement)
 	}
 	return expr, err
 }
@@ -81,6 +495,10 @@ func (p *Parser) identity() (ast.Identity, error) {
 	return ast.Identity{}, nil
 }
 
+func (p *Parser) recurse() (ast.Recurse, error) {
+	return ast.Recurse{}, nil
+}
+
 func (p *Parser) selector() (ast.Selector, error) {
 	var expr ast.Selector
 	var err error
@@ -92,17 +510,26 @@ func (p *Parser) selector() (ast.Selector, error) {
 		s, _ := p.string()
 		expr.Value = &s
 	case p.match(lexer.Colon):
-		s, _ := p.span(nil)
+		var s ast.Span
+		s, err = p.span(nil)
 		expr.Value = &s
 	case p.match(lexer.Integer):
-		i, _ := p.integer()
+		var i ast.Integer
+		i, err = p.integer()
+		if err != nil {
+			break
+		}
 		if p.match(lexer.Colon) {
-			s, _ := p.span(&i)
+			var s ast.Span
+			s, err = p.span(&i)
 			expr.Value = &s
 		} else {
 			expr.Value = &i
 		}
 	}
+	if err != nil {
+		return expr, err
+	}
 	_, err = p.consume(lexer.ArrayClose, ErrSelectorUnterminated)
 	return expr, err
 }
@@ -115,16 +542,269 @@ func (p *Parser) string() (ast.String, error) {
 	return ast.String{Value: p.previous().Lexeme()}, nil
 }
 
+// integer parses the index, the span bound or the span step. Integers that do
+// not fit in the int type cannot be represented.
 func (p *Parser) integer() (ast.Integer, error) {
-	return ast.Integer{Value: p.previous().Lexeme()}, nil
+	t := p.previous()
+	i := ast.Integer{Value: t.Lexeme()}
+	if _, err := i.Vtoi(); err != nil {
+		return i, &Error{t.Lexeme(), t.Buffer, t.Start, t.LineOffset, ErrLiteral}
+	}
+	return i, nil
+}
+
+func (p *Parser) literal() (ast.Literal, error) {
+	t := p.previous()
+	var v any
+	var err error
+	switch t.Type {
+	case lexer.Integer:
+		v, err = strconv.ParseInt(t.Lexeme(), 10, 64)
+	case lexer.Float:
+		v, err = parseFloat(t.Lexeme())
+	case lexer.Boolean:
+		v = t.Lexeme() == "true"
+	case lexer.DateTime:
+		v, err = parseDateTime(t.Lexeme())
+	default:
+		v = t.Lexeme()
+	}
+	if err != nil {
+		err := &Error{t.Lexeme(), t.Buffer, t.Start, t.LineOffset, ErrLiteral}
+		return ast.Literal{}, err
+	}
+	return ast.Literal{Value: v}, nil
+}
+
+// parseFloat parses the float s including the TOML special float values.
+func parseFloat(s string) (float64, error) {
+	switch s {
+	case "inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	case "nan":
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseDateTime parses the date-time s into the value of the same type that
+// the TOML decoder produces for it: time.Time for the offset date-time and
+// toml.LocalDateTime or toml.LocalDate for the local ones.
+func parseDateTime(s string) (any, error) {
+	s = strings.ToUpper(s)
+	if v, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return v, nil
+	}
+	if strings.Contains(s, "T") {
+		var v toml.LocalDateTime
+		err := v.UnmarshalText([]byte(s))
+		return v, err
+	}
+	var v toml.LocalDate
+	err := v.UnmarshalText([]byte(s))
+	return v, err
+}
+
+// call parses the function call. Arguments in parentheses are separated with
+// semicolons, and the parentheses are omitted for functions without arguments.
+func (p *Parser) call() (ast.Call, error) {
+	expr := ast.Call{Name: p.previous().Lexeme()}
+	if !p.match(lexer.ParenOpen) {
+		return expr, nil
+	}
+	for {
+		arg, err := p.pipe()
+		if err != nil {
+			return expr, err
+		}
+		expr.Args = append(expr.Args, arg)
+		if !p.match(lexer.Semicolon) {
+			break
+		}
+	}
+	_, err := p.consume(lexer.ParenClose, ErrParenUnterminated)
+	return expr, err
+}
+
+// interpolation parses the quoted string with interpolated expressions. The
+// string parts around the expressions become string literals, and the empty
+// ones are left out.
+func (p *Parser) interpolation() (ast.Interpolation, error) {
+	var expr ast.Interpolation
+	for {
+		if s := p.previous().Lexeme(); s != "" {
+			expr.Parts = append(expr.Parts, &ast.Literal{Value: s})
+		}
+		if p.previous().Type == lexer.InterpolationEnd {
+			return expr, nil
+		}
+		e, err := p.pipe()
+		if err != nil {
+			return expr, err
+		}
+		expr.Parts = append(expr.Parts, e)
+		if !p.match(lexer.InterpolationMiddle, lexer.InterpolationEnd) {
+			return expr, p.errorAtPeek(ErrInterpolationUnterminated)
+		}
+	}
+}
+
+// variable parses the variable reference. The $__loc__ variable is replaced
+// with the table holding the location of the reference in the query.
+func (p *Parser) variable() ast.Expr {
+	t := p.previous()
+	name := variableName(t)
+	if name == "__loc__" {
+		loc := map[string]any{"file": "<query>", "line": int64(t.Line())}
+		return &ast.Literal{Value: loc}
+	}
+	return &ast.Variable{Name: name}
+}
+
+// format parses the format string. The format string followed by a quoted
+// string with interpolated expressions applies to outputs of these
+// expressions, and the quoted string without them stays as it is.
+func (p *Parser) format() (ast.Expr, error) {
+	name := strings.TrimPrefix(p.previous().Lexeme(), "@")
+	switch {
+	case p.match(lexer.InterpolationStart):
+		i, err := p.interpolation()
+		i.Format = name
+		return &i, err
+	case p.checkQuoted():
+		p.advance()
+		l, err := p.literal()
+		return &l, err
+	}
+	return &ast.Format{Name: name}, nil
+}
+
+// group parses the parenthesized expression. It does not have an AST node of
+// its own, since parentheses only override the precedence of operators.
+func (p *Parser) group() (ast.Expr, error) {
+	expr, err := p.pipe()
+	if err != nil {
+		return expr, err
+	}
+	_, err = p.consume(lexer.ParenClose, ErrParenUnterminated)
+	return expr, err
+}
+
+func (p *Parser) array() (ast.Array, error) {
+	var expr ast.Array
+	var err error
+	if p.match(lexer.ArrayClose) {
+		return expr, nil
+	}
+	expr.Value, err = p.pipe()
+	if err != nil {
+		return expr, err
+	}
+	_, err = p.consume(lexer.ArrayClose, ErrArrayUnterminated)
+	return expr, err
+}
+
+func (p *Parser) object() (ast.Object, error) {
+	var expr ast.Object
+	if p.match(lexer.ObjectClose) {
+		return expr, nil
+	}
+	for {
+		e, err := p.objectEntry()
+		if err != nil {
+			return expr, err
+		}
+		expr.Entries = append(expr.Entries, e)
+		if !p.match(lexer.Comma) {
+			break
+		}
+	}
+	_, err := p.consume(lexer.ObjectClose, ErrObjectUnterminated)
+	return expr, err
+}
+
+// objectEntry parses a single entry of the object construction. The key is
+// either a parenthesized expression or a string. The value of the string key
+// may be omitted, and it then defaults to the value stored under the key.
+func (p *Parser) objectEntry() (ast.ObjectEntry, error) {
+	var expr ast.ObjectEntry
+	var err error
+	switch {
+	case p.match(lexer.ParenOpen):
+		expr.Key, err = p.group()
+		if err == nil {
+			_, err = p.consume(lexer.Colon, ErrQueryElement)
+		}
+		if err == nil {
+			expr.Value, err = p.objectValue()
+		}
+	case p.match(lexer.InterpolationStart):
+		var i ast.Interpolation
+		i, err = p.interpolation()
+		expr.Key = &i
+		if err == nil {
+			_, err = p.consume(lexer.Colon, ErrQueryElement)
+		}
+		if err == nil {
+			expr.Value, err = p.objectValue()
+		}
+	case p.match(lexer.Variable):
+		expr.Key = &ast.Literal{Value: variableName(p.previous())}
+		expr.Value = p.variable()
+	case p.checkObjectKey():
+		key := p.advance().Lexeme()
+		expr.Key = &ast.Literal{Value: key}
+		if p.match(lexer.Colon) {
+			expr.Value, err = p.objectValue()
+			break
+		}
+		expr.Value = &ast.Query{
+			Filters: []ast.Expr{
+				&ast.Filter{Kind: &ast.Identity{}},
+				&ast.Filter{Kind: &ast.String{Value: key}},
+			},
+		}
+	default:
+		err = p.errorAtPeek(ErrQueryElement)
+	}
+	return expr, err
+}
+
+// objectValue parses the value of the object entry. Commas separate entries,
+// so the value is a pipe of expressions that are not comma-separated.
+func (p *Parser) objectValue() (ast.Expr, error) {
+	left, err := p.or()
+	if err != nil || !p.match(lexer.Pipe) {
+		return left, err
+	}
+	right, err := p.objectValue()
+	expr := ast.Pipe{Left: left, Right: right}
+	return &expr, err
 }
 
 func (p *Parser) span(left *ast.Integer) (ast.Span, error) {
 	s := ast.Span{Left: left}
 	if p.match(lexer.Integer) {
-		r, _ := p.integer()
+		r, err := p.integer()
+		if err != nil {
+			return s, err
+		}
 		s.Right = &r
 	}
+	if p.match(lexer.Colon) && p.match(lexer.Integer) {
+		step, err := p.integer()
+		if err != nil {
+			return s, err
+		}
+		if v, _ := step.Vtoi(); v == 0 {
+			t := p.previous()
+			err := &Error{t.Lexeme(), t.Buffer, t.Start, t.LineOffset, ErrSpanStep}
+			return s, err
+		}
+		s.Step = &step
+	}
 	return s, nil
 }
 
@@ -132,14 +812,74 @@ func (p *Parser) consume(t lexer.TokenType, e error) (lexer.Token, error) {
 	if p.check(t) {
 		return p.advance(), nil
 	}
+	return lexer.Token{}, p.errorAtPeek(e)
+}
+
+func (p *Parser) errorAtPeek(e error) error {
 	v, err := p.peek()
 	if err != nil {
 		// NOTE: EOL is not something that can be pointed at hence +1.
-		err := &Error{"EOL", v.Buffer, len(*v.Buffer), v.LineOffset + 1, e}
-		return lexer.Token{}, err
+		return &Error{"EOL", v.Buffer, len(*v.Buffer), v.LineOffset + 1, e}
+	}
+	return &Error{v.Lexeme(), v.Buffer, v.Start, v.LineOffset, e}
+}
+
+// checkFilter reports if the next token opens a query filter element.
+func (p *Parser) checkFilter() bool {
+	return p.check(lexer.Dot) ||
+		p.check(lexer.DoubleDot) ||
+		p.check(lexer.ArrayOpen) ||
+		p.check(lexer.String) ||
+		p.checkKey()
+}
+
+// checkTerm reports if the next token opens a query with an element that is
+// not a filter: quoted strings stand for string literals and bare strings for
+// function calls in this position. Brackets stand for the array construction,
+// so selectors on the input data need a dot in front, as in `.[0]`.
+func (p *Parser) checkTerm() bool {
+	return p.check(lexer.String) ||
+		p.check(lexer.Integer) ||
+		p.check(lexer.Float) ||
+		p.check(lexer.Boolean) ||
+		p.check(lexer.DateTime) ||
+		p.check(lexer.InterpolationStart) ||
+		p.check(lexer.Format) ||
+		p.check(lexer.Variable) ||
+		p.check(lexer.Reduce) ||
+		p.check(lexer.Foreach) ||
+		p.check(lexer.ParenOpen) ||
+		p.check(lexer.ObjectOpen) ||
+		p.check(lexer.ArrayOpen)
+}
+
+// checkQuoted reports if the next token is a quoted string.
+func (p *Parser) checkQuoted() bool {
+	v, err := p.peek()
+	return err == nil && v.Type == lexer.String && v.Quoted()
+}
+
+// checkBare reports if the next token is a bare string.
+func (p *Parser) checkBare() bool {
+	v, err := p.peek()
+	return err == nil && v.Type == lexer.String && !v.Quoted()
+}
+
+// checkObjectKey reports if the next token is a string or a keyword that can
+// stand for a key of the object entry.
+func (p *Parser) checkObjectKey() bool {
+	v, err := p.peek()
+	return err == nil && (v.Type == lexer.String || v.Keyword())
+}
+
+// checkKey reports if the next token is a keyword that immediately follows a
+// dot. In this position the keyword is a bare table key like any other.
+func (p *Parser) checkKey() bool {
+	if p.isAtEnd() || p.current == 0 {
+		return false
 	}
-	err = &Error{v.Lexeme(), v.Buffer, v.Start, v.LineOffset, e}
-	return lexer.Token{}, err
+	v, prev := p.buffer[p.current], p.previous()
+	return v.Keyword() && prev.Type == lexer.Dot && v.Start == prev.End
 }
 
 func (p *Parser) match(tt ...lexer.TokenType) bool {
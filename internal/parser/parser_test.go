@@ -2,13 +2,16 @@ package parser
 
 import (
 	"errors"
+	"math"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/mdm-code/scanner"
 	"github.com/mdm-code/tq/v2/internal/ast"
 	"github.com/mdm-code/tq/v2/internal/lexer"
+	"github.com/pelletier/go-toml/v2"
 )
 
 // Test the failing Parser New() constructor.
@@ -33,10 +36,230 @@ func TestParseErrored(t *testing.T) {
 			query: ".[]]",
 			want:  ErrQueryElement,
 		},
+		{
+			query: ".a * -",
+			want:  ErrQueryElement,
+		},
 		{
 			query: "['interfaces'][0",
 			want:  ErrSelectorUnterminated,
 		},
+		{
+			query: ".servers |",
+			want:  ErrQueryElement,
+		},
+		{
+			query: "| .ip",
+			want:  ErrQueryElement,
+		},
+		{
+			query: ".servers | | .ip",
+			want:  ErrQueryElement,
+		},
+		{
+			query: ".host, ",
+			want:  ErrQueryElement,
+		},
+		{
+			query: ".host,, .port",
+			want:  ErrQueryElement,
+		},
+		{
+			query: ".[1:2:0]",
+			want:  ErrSpanStep,
+		},
+		{
+			query: ".[99999999999999999999]",
+			want:  ErrLiteral,
+		},
+		{
+			query: ".arr[:99999999999999999999]",
+			want:  ErrLiteral,
+		},
+		{
+			query: ".arr[99999999999999999999:]",
+			want:  ErrLiteral,
+		},
+		{
+			query: ".arr[::99999999999999999999]",
+			want:  ErrLiteral,
+		},
+		{
+			query: "?.tags",
+			want:  ErrQueryElement,
+		},
+		{
+			query: ".tags | ?",
+			want:  ErrQueryElement,
+		},
+		{
+			query: ".a == .b == .c",
+			want:  ErrQueryElement,
+		},
+		{
+			query: ".port >",
+			want:  ErrQueryElement,
+		},
+		{
+			query: ".enabled and",
+			want:  ErrQueryElement,
+		},
+		{
+			query: ". == 99999999999999999999",
+			want:  ErrLiteral,
+		},
+		{
+			query: "1e999",
+			want:  ErrLiteral,
+		},
+		{
+			query: "1979-13-27",
+			want:  ErrLiteral,
+		},
+		{
+			query: "1979-05-27T25:32:00Z",
+			want:  ErrLiteral,
+		},
+		{
+			query: "select(.enabled",
+			want:  ErrParenUnterminated,
+		},
+		{
+			query: "select()",
+			want:  ErrQueryElement,
+		},
+		{
+			query: "has(.a;)",
+			want:  ErrQueryElement,
+		},
+		{
+			query: "has(.a; .b",
+			want:  ErrParenUnterminated,
+		},
+		{
+			query: ".a +",
+			want:  ErrQueryElement,
+		},
+		{
+			query: "* 2",
+			want:  ErrQueryElement,
+		},
+		{
+			query: "(1 + 2",
+			want:  ErrParenUnterminated,
+		},
+		{
+			query: "[.servers[].ip",
+			want:  ErrArrayUnterminated,
+		},
+		{
+			query: ".[0",
+			want:  ErrSelectorUnterminated,
+		},
+		{
+			query: "[0",
+			want:  ErrArrayUnterminated,
+		},
+		{
+			query: "[1, ]",
+			want:  ErrQueryElement,
+		},
+		{
+			query: "{name: .hostname",
+			want:  ErrObjectUnterminated,
+		},
+		{
+			query: "{name .hostname}",
+			want:  ErrObjectUnterminated,
+		},
+		{
+			query: "{1: .hostname}",
+			want:  ErrQueryElement,
+		},
+		{
+			query: "{(.key) .value}",
+			want:  ErrQueryElement,
+		},
+		{
+			query: "{name:}",
+			want:  ErrQueryElement,
+		},
+		{
+			query: `"\(.host]`,
+			want:  ErrInterpolationUnterminated,
+		},
+		{
+			query: `"\()"`,
+			want:  ErrQueryElement,
+		},
+		{
+			query: ".a as x | .",
+			want:  ErrBindingPattern,
+		},
+		{
+			query: ".a as $x",
+			want:  ErrBindingBody,
+		},
+		{
+			query: ". as $a ?// | .",
+			want:  ErrBindingPattern,
+		},
+		{
+			query: ". as {1: $a} | .",
+			want:  ErrBindingPattern,
+		},
+		{
+			query: ". as {a} | .",
+			want:  ErrQueryElement,
+		},
+		{
+			query: ". as [$a | .",
+			want:  ErrArrayUnterminated,
+		},
+		{
+			query: ". as {$a | .",
+			want:  ErrObjectUnterminated,
+		},
+		{
+			query: "def 1: .; .",
+			want:  ErrDefinitionName,
+		},
+		{
+			query: "def f(1): .; .",
+			want:  ErrDefinitionParam,
+		},
+		{
+			query: "def f(g: .; .",
+			want:  ErrParenUnterminated,
+		},
+		{
+			query: "def f .; .",
+			want:  ErrDefinitionBody,
+		},
+		{
+			query: "def f: .",
+			want:  ErrDefinitionUnterminated,
+		},
+		{
+			query: "reduce .[] (0; .)",
+			want:  ErrFoldSource,
+		},
+		{
+			query: "reduce .[] as $x 0; .",
+			want:  ErrFoldState,
+		},
+		{
+			query: "foreach .[] as $x (0)",
+			want:  ErrFoldUpdate,
+		},
+		{
+			query: "foreach .[] as $x (0; .; .; .)",
+			want:  ErrParenUnterminated,
+		},
+		{
+			query: "reduce .[] as 1 (0; .)",
+			want:  ErrBindingPattern,
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.query, func(t *testing.T) {
@@ -122,10 +345,13 @@ func TestParse(t *testing.T) {
 			},
 		},
 		{
-			query: "[\"employees\"][10:][][\"salary\"][12]",
+			query: ".[\"employees\"][10:][][\"salary\"][12]",
 			want: &ast.Root{
 				Query: &ast.Query{
 					Filters: []ast.Expr{
+						&ast.Filter{
+							Kind: &ast.Identity{},
+						},
 						&ast.Filter{
 							Kind: &ast.Selector{
 								Value: &ast.String{
@@ -220,40 +446,1417 @@ func TestParse(t *testing.T) {
 			},
 		},
 		{
-			query: "",
+			query: ".servers[] | .ip",
 			want: &ast.Root{
-				Query: &ast.Query{},
+				Query: &ast.Pipe{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.String{
+									Value: "servers",
+								},
+							},
+							&ast.Filter{
+								Kind: &ast.Selector{
+									Value: &ast.Iterator{},
+								},
+							},
+						},
+					},
+					Right: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.String{
+									Value: "ip",
+								},
+							},
+						},
+					},
+				},
 			},
 		},
 		{
-			query: ".",
+			query: ". | [0] | .",
+			want: &ast.Root{
+				Query: &ast.Pipe{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+						},
+					},
+					Right: &ast.Pipe{
+						Left: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Array{
+										Value: &ast.Query{
+											Filters: []ast.Expr{
+												&ast.Filter{
+													Kind: &ast.Literal{
+														Value: int64(0),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+						Right: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Identity{},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: ".server | .host, .port, [0]",
+			want: &ast.Root{
+				Query: &ast.Pipe{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.String{
+									Value: "server",
+								},
+							},
+						},
+					},
+					Right: &ast.Comma{
+						Left: &ast.Comma{
+							Left: &ast.Query{
+								Filters: []ast.Expr{
+									&ast.Filter{
+										Kind: &ast.Identity{},
+									},
+									&ast.Filter{
+										Kind: &ast.String{
+											Value: "host",
+										},
+									},
+								},
+							},
+							Right: &ast.Query{
+								Filters: []ast.Expr{
+									&ast.Filter{
+										Kind: &ast.Identity{},
+									},
+									&ast.Filter{
+										Kind: &ast.String{
+											Value: "port",
+										},
+									},
+								},
+							},
+						},
+						Right: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Array{
+										Value: &ast.Query{
+											Filters: []ast.Expr{
+												&ast.Filter{
+													Kind: &ast.Literal{
+														Value: int64(0),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: ".. | .ip",
+			want: &ast.Root{
+				Query: &ast.Pipe{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Recurse{},
+							},
+						},
+					},
+					Right: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.String{
+									Value: "ip",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: ".[-1][-3:-1]",
 			want: &ast.Root{
 				Query: &ast.Query{
 					Filters: []ast.Expr{
 						&ast.Filter{
 							Kind: &ast.Identity{},
 						},
+						&ast.Filter{
+							Kind: &ast.Selector{
+								Value: &ast.Integer{
+									Value: "-1",
+								},
+							},
+						},
+						&ast.Filter{
+							Kind: &ast.Selector{
+								Value: &ast.Span{
+									Left: &ast.Integer{
+										Value: "-3",
+									},
+									Right: &ast.Integer{
+										Value: "-1",
+									},
+								},
+							},
+						},
 					},
 				},
 			},
 		},
-	}
-	for _, c := range cases {
-		t.Run(c.query, func(t *testing.T) {
-			r := strings.NewReader(c.query)
-			s, _ := scanner.New(r)
-			l, _ := lexer.New(s)
-			p, err := New(l)
-			if err != nil {
-				t.Fatal(err)
-			}
-			have, err := p.Parse()
-			if err != nil {
-				t.Fatal(err)
-			}
-			if !reflect.DeepEqual(have, c.want) {
-				t.Errorf("have: %v; want: %v", have, c.want)
-			}
-		})
+		{
+			query: ".[::-1][1:9:2]",
+			want: &ast.Root{
+				Query: &ast.Query{
+					Filters: []ast.Expr{
+						&ast.Filter{
+							Kind: &ast.Identity{},
+						},
+						&ast.Filter{
+							Kind: &ast.Selector{
+								Value: &ast.Span{
+									Step: &ast.Integer{
+										Value: "-1",
+									},
+								},
+							},
+						},
+						&ast.Filter{
+							Kind: &ast.Selector{
+								Value: &ast.Span{
+									Left: &ast.Integer{
+										Value: "1",
+									},
+									Right: &ast.Integer{
+										Value: "9",
+									},
+									Step: &ast.Integer{
+										Value: "2",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: ".servers[].tags[]?",
+			want: &ast.Root{
+				Query: &ast.Query{
+					Filters: []ast.Expr{
+						&ast.Filter{
+							Kind: &ast.Identity{},
+						},
+						&ast.Filter{
+							Kind: &ast.String{
+								Value: "servers",
+							},
+						},
+						&ast.Filter{
+							Kind: &ast.Selector{
+								Value: &ast.Iterator{},
+							},
+						},
+						&ast.Filter{
+							Kind: &ast.Identity{},
+						},
+						&ast.Filter{
+							Kind: &ast.String{
+								Value: "tags",
+							},
+						},
+						&ast.Filter{
+							Kind: &ast.Optional{
+								Value: &ast.Selector{
+									Value: &ast.Iterator{},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: ".role == \"backend\"",
+			want: &ast.Root{
+				Query: &ast.Binary{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.String{
+									Value: "role",
+								},
+							},
+						},
+					},
+					Operator: "==",
+					Right: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Literal{
+									Value: "backend",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: ".and or not and .or >= -1",
+			want: &ast.Root{
+				Query: &ast.Logical{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.String{
+									Value: "and",
+								},
+							},
+						},
+					},
+					Operator: "or",
+					Right: &ast.Logical{
+						Left: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Call{
+										Name: "not",
+									},
+								},
+							},
+						},
+						Operator: "and",
+						Right: &ast.Binary{
+							Left: &ast.Query{
+								Filters: []ast.Expr{
+									&ast.Filter{
+										Kind: &ast.Identity{},
+									},
+									&ast.Filter{
+										Kind: &ast.String{
+											Value: "or",
+										},
+									},
+								},
+							},
+							Operator: ">=",
+							Right: &ast.Query{
+								Filters: []ast.Expr{
+									&ast.Filter{
+										Kind: &ast.Literal{
+											Value: int64(-1),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: ".servers[] | select(.enabled) | .select",
+			want: &ast.Root{
+				Query: &ast.Pipe{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.String{
+									Value: "servers",
+								},
+							},
+							&ast.Filter{
+								Kind: &ast.Selector{
+									Value: &ast.Iterator{},
+								},
+							},
+						},
+					},
+					Right: &ast.Pipe{
+						Left: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Call{
+										Name: "select",
+										Args: []ast.Expr{
+											&ast.Query{
+												Filters: []ast.Expr{
+													&ast.Filter{
+														Kind: &ast.Identity{},
+													},
+													&ast.Filter{
+														Kind: &ast.String{
+															Value: "enabled",
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+						Right: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Identity{},
+								},
+								&ast.Filter{
+									Kind: &ast.String{
+										Value: "select",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: ".a -1 * (2 + 3)",
+			want: &ast.Root{
+				Query: &ast.Binary{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.String{
+									Value: "a",
+								},
+							},
+						},
+					},
+					Operator: "-",
+					Right: &ast.Binary{
+						Left: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Literal{
+										Value: int64(1),
+									},
+								},
+							},
+						},
+						Operator: "*",
+						Right: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Binary{
+										Left: &ast.Query{
+											Filters: []ast.Expr{
+												&ast.Filter{
+													Kind: &ast.Literal{
+														Value: int64(2),
+													},
+												},
+											},
+										},
+										Operator: "+",
+										Right: &ast.Query{
+											Filters: []ast.Expr{
+												&ast.Filter{
+													Kind: &ast.Literal{
+														Value: int64(3),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: ".a * -.b",
+			want: &ast.Root{
+				Query: &ast.Binary{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.String{
+									Value: "a",
+								},
+							},
+						},
+					},
+					Operator: "*",
+					Right: &ast.Negation{
+						Value: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Identity{},
+								},
+								&ast.Filter{
+									Kind: &ast.String{
+										Value: "b",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "- -(.a)",
+			want: &ast.Root{
+				Query: &ast.Negation{
+					Value: &ast.Negation{
+						Value: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Query{
+										Filters: []ast.Expr{
+											&ast.Filter{
+												Kind: &ast.Identity{},
+											},
+											&ast.Filter{
+												Kind: &ast.String{
+													Value: "a",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "{name: .hostname | .short, ip, (.key): 1}",
+			want: &ast.Root{
+				Query: &ast.Query{
+					Filters: []ast.Expr{
+						&ast.Filter{
+							Kind: &ast.Object{
+								Entries: []ast.ObjectEntry{
+									{
+										Key: &ast.Literal{
+											Value: "name",
+										},
+										Value: &ast.Pipe{
+											Left: &ast.Query{
+												Filters: []ast.Expr{
+													&ast.Filter{
+														Kind: &ast.Identity{},
+													},
+													&ast.Filter{
+														Kind: &ast.String{
+															Value: "hostname",
+														},
+													},
+												},
+											},
+											Right: &ast.Query{
+												Filters: []ast.Expr{
+													&ast.Filter{
+														Kind: &ast.Identity{},
+													},
+													&ast.Filter{
+														Kind: &ast.String{
+															Value: "short",
+														},
+													},
+												},
+											},
+										},
+									},
+									{
+										Key: &ast.Literal{
+											Value: "ip",
+										},
+										Value: &ast.Query{
+											Filters: []ast.Expr{
+												&ast.Filter{
+													Kind: &ast.Identity{},
+												},
+												&ast.Filter{
+													Kind: &ast.String{
+														Value: "ip",
+													},
+												},
+											},
+										},
+									},
+									{
+										Key: &ast.Query{
+											Filters: []ast.Expr{
+												&ast.Filter{
+													Kind: &ast.Identity{},
+												},
+												&ast.Filter{
+													Kind: &ast.String{
+														Value: "key",
+													},
+												},
+											},
+										},
+										Value: &ast.Query{
+											Filters: []ast.Expr{
+												&ast.Filter{
+													Kind: &ast.Literal{
+														Value: int64(1),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "[.ip, 1][0]",
+			want: &ast.Root{
+				Query: &ast.Query{
+					Filters: []ast.Expr{
+						&ast.Filter{
+							Kind: &ast.Array{
+								Value: &ast.Comma{
+									Left: &ast.Query{
+										Filters: []ast.Expr{
+											&ast.Filter{
+												Kind: &ast.Identity{},
+											},
+											&ast.Filter{
+												Kind: &ast.String{
+													Value: "ip",
+												},
+											},
+										},
+									},
+									Right: &ast.Query{
+										Filters: []ast.Expr{
+											&ast.Filter{
+												Kind: &ast.Literal{
+													Value: int64(1),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+						&ast.Filter{
+							Kind: &ast.Selector{
+								Value: &ast.Integer{
+									Value: "0",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: ".[1:]",
+			want: &ast.Root{
+				Query: &ast.Query{
+					Filters: []ast.Expr{
+						&ast.Filter{
+							Kind: &ast.Identity{},
+						},
+						&ast.Filter{
+							Kind: &ast.Selector{
+								Value: &ast.Span{
+									Left: &ast.Integer{
+										Value: "1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "has(\"a\"; .b) | servers[]",
+			want: &ast.Root{
+				Query: &ast.Pipe{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Call{
+									Name: "has",
+									Root: true,
+									Args: []ast.Expr{
+										&ast.Query{
+											Filters: []ast.Expr{
+												&ast.Filter{
+													Kind: &ast.Literal{
+														Value: "a",
+													},
+												},
+											},
+										},
+										&ast.Query{
+											Filters: []ast.Expr{
+												&ast.Filter{
+													Kind: &ast.Identity{},
+												},
+												&ast.Filter{
+													Kind: &ast.String{
+														Value: "b",
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Right: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Call{
+									Name: "servers",
+								},
+							},
+							&ast.Filter{
+								Kind: &ast.Selector{
+									Value: &ast.Iterator{},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "{}",
+			want: &ast.Root{
+				Query: &ast.Query{
+					Filters: []ast.Expr{
+						&ast.Filter{
+							Kind: &ast.Object{},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "",
+			want: &ast.Root{
+				Query: &ast.Query{},
+			},
+		},
+		{
+			query: ".",
+			want: &ast.Root{
+				Query: &ast.Query{
+					Filters: []ast.Expr{
+						&ast.Filter{
+							Kind: &ast.Identity{},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: `"\(.host):\(1)" | {"\(.)": 1}`,
+			want: &ast.Root{
+				Query: &ast.Pipe{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Interpolation{
+									Parts: []ast.Expr{
+										&ast.Query{
+											Filters: []ast.Expr{
+												&ast.Filter{
+													Kind: &ast.Identity{},
+												},
+												&ast.Filter{
+													Kind: &ast.String{
+														Value: "host",
+													},
+												},
+											},
+										},
+										&ast.Literal{
+											Value: ":",
+										},
+										&ast.Query{
+											Filters: []ast.Expr{
+												&ast.Filter{
+													Kind: &ast.Literal{
+														Value: int64(1),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Right: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Object{
+									Entries: []ast.ObjectEntry{
+										{
+											Key: &ast.Interpolation{
+												Parts: []ast.Expr{
+													&ast.Query{
+														Filters: []ast.Expr{
+															&ast.Filter{
+																Kind: &ast.Identity{},
+															},
+														},
+													},
+												},
+											},
+											Value: &ast.Query{
+												Filters: []ast.Expr{
+													&ast.Filter{
+														Kind: &ast.Literal{
+															Value: int64(1),
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: `@csv, @sh "rm \(.)", @json "x"`,
+			want: &ast.Root{
+				Query: &ast.Comma{
+					Left: &ast.Comma{
+						Left: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Format{
+										Name: "csv",
+									},
+								},
+							},
+						},
+						Right: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Interpolation{
+										Format: "sh",
+										Parts: []ast.Expr{
+											&ast.Literal{
+												Value: "rm ",
+											},
+											&ast.Query{
+												Filters: []ast.Expr{
+													&ast.Filter{
+														Kind: &ast.Identity{},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Right: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Literal{
+									Value: "x",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: ".a as $x | $x, $__loc__",
+			want: &ast.Root{
+				Query: &ast.Binding{
+					Source: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+							&ast.Filter{
+								Kind: &ast.String{
+									Value: "a",
+								},
+							},
+						},
+					},
+					Patterns: []ast.Expr{
+						&ast.Variable{
+							Name: "x",
+						},
+					},
+					Body: &ast.Comma{
+						Left: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Variable{
+										Name: "x",
+									},
+								},
+							},
+						},
+						Right: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Literal{
+										Value: map[string]any{
+											"file": "<query>",
+											"line": int64(1),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "1 * 2 as $x | {$x}",
+			want: &ast.Root{
+				Query: &ast.Binary{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Literal{
+									Value: int64(1),
+								},
+							},
+						},
+					},
+					Operator: "*",
+					Right: &ast.Binding{
+						Source: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Literal{
+										Value: int64(2),
+									},
+								},
+							},
+						},
+						Patterns: []ast.Expr{
+							&ast.Variable{
+								Name: "x",
+							},
+						},
+						Body: &ast.Query{
+							Filters: []ast.Expr{
+								&ast.Filter{
+									Kind: &ast.Object{
+										Entries: []ast.ObjectEntry{
+											{
+												Key: &ast.Literal{
+													Value: "x",
+												},
+												Value: &ast.Variable{
+													Name: "x",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: `. as {host: $h, $p: [$first]} ?// [$h] | $h`,
+			want: &ast.Root{
+				Query: &ast.Binding{
+					Source: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+						},
+					},
+					Patterns: []ast.Expr{
+						&ast.ObjectPattern{
+							Entries: []ast.ObjectEntry{
+								{
+									Key: &ast.Literal{
+										Value: "host",
+									},
+									Value: &ast.Variable{
+										Name: "h",
+									},
+								},
+								{
+									Key: &ast.Variable{
+										Name: "p",
+									},
+									Value: &ast.ArrayPattern{
+										Elements: []ast.Expr{
+											&ast.Variable{
+												Name: "first",
+											},
+										},
+									},
+								},
+							},
+						},
+						&ast.ArrayPattern{
+							Elements: []ast.Expr{
+								&ast.Variable{
+									Name: "h",
+								},
+							},
+						},
+					},
+					Body: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Variable{
+									Name: "h",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "def f(g; $a): g; f(.; 1)",
+			want: &ast.Root{
+				Query: &ast.Definition{
+					Name:   "f",
+					Params: []string{"g", "$a"},
+					Body: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Call{
+									Name: "g",
+								},
+							},
+						},
+					},
+					Rest: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Call{
+									Name: "f",
+									Args: []ast.Expr{
+										&ast.Query{
+											Filters: []ast.Expr{
+												&ast.Filter{
+													Kind: &ast.Identity{},
+												},
+											},
+										},
+										&ast.Query{
+											Filters: []ast.Expr{
+												&ast.Filter{
+													Kind: &ast.Literal{
+														Value: int64(1),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "reduce .[] as $x (0; $x)",
+			want: &ast.Root{
+				Query: &ast.Query{
+					Filters: []ast.Expr{
+						&ast.Filter{
+							Kind: &ast.Reduce{
+								Source: &ast.Query{
+									Filters: []ast.Expr{
+										&ast.Filter{
+											Kind: &ast.Identity{},
+										},
+										&ast.Filter{
+											Kind: &ast.Selector{
+												Value: &ast.Iterator{},
+											},
+										},
+									},
+								},
+								Patterns: []ast.Expr{
+									&ast.Variable{
+										Name: "x",
+									},
+								},
+								Init: &ast.Query{
+									Filters: []ast.Expr{
+										&ast.Filter{
+											Kind: &ast.Literal{
+												Value: int64(0),
+											},
+										},
+									},
+								},
+								Update: &ast.Query{
+									Filters: []ast.Expr{
+										&ast.Filter{
+											Kind: &ast.Variable{
+												Name: "x",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "foreach $a as [$x] (.; .)",
+			want: &ast.Root{
+				Query: &ast.Query{
+					Filters: []ast.Expr{
+						&ast.Filter{
+							Kind: &ast.Foreach{
+								Source: &ast.Query{
+									Filters: []ast.Expr{
+										&ast.Filter{
+											Kind: &ast.Variable{
+												Name: "a",
+											},
+										},
+									},
+								},
+								Patterns: []ast.Expr{
+									&ast.ArrayPattern{
+										Elements: []ast.Expr{
+											&ast.Variable{
+												Name: "x",
+											},
+										},
+									},
+								},
+								Init: &ast.Query{
+									Filters: []ast.Expr{
+										&ast.Filter{
+											Kind: &ast.Identity{},
+										},
+									},
+								},
+								Update: &ast.Query{
+									Filters: []ast.Expr{
+										&ast.Filter{
+											Kind: &ast.Identity{},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: ". | []",
+			want: &ast.Root{
+				Query: &ast.Pipe{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Identity{},
+							},
+						},
+					},
+					Right: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Array{},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "1, [[1]]",
+			want: &ast.Root{
+				Query: &ast.Comma{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Literal{
+									Value: int64(1),
+								},
+							},
+						},
+					},
+					Right: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Array{
+									Value: &ast.Query{
+										Filters: []ast.Expr{
+											&ast.Filter{
+												Kind: &ast.Array{
+													Value: &ast.Query{
+														Filters: []ast.Expr{
+															&ast.Filter{
+																Kind: &ast.Literal{
+																	Value: int64(1),
+																},
+															},
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "[1] | length",
+			want: &ast.Root{
+				Query: &ast.Pipe{
+					Left: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Array{
+									Value: &ast.Query{
+										Filters: []ast.Expr{
+											&ast.Filter{
+												Kind: &ast.Literal{
+													Value: int64(1),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Right: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Call{
+									Name: "length",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "[1] as [$a] | $a",
+			want: &ast.Root{
+				Query: &ast.Binding{
+					Source: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Array{
+									Value: &ast.Query{
+										Filters: []ast.Expr{
+											&ast.Filter{
+												Kind: &ast.Literal{
+													Value: int64(1),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Patterns: []ast.Expr{
+						&ast.ArrayPattern{
+							Elements: []ast.Expr{
+								&ast.Variable{
+									Name: "a",
+								},
+							},
+						},
+					},
+					Body: &ast.Query{
+						Filters: []ast.Expr{
+							&ast.Filter{
+								Kind: &ast.Variable{
+									Name: "a",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			r := strings.NewReader(c.query)
+			s, _ := scanner.New(r)
+			l, _ := lexer.New(s)
+			p, err := New(l)
+			if err != nil {
+				t.Fatal(err)
+			}
+			have, err := p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %v; want: %v", have, c.want)
+			}
+		})
+	}
+}
+
+// Check if literal values in the query are parsed into values of the same Go
+// types that the TOML decoder produces.
+func TestParseLiteral(t *testing.T) {
+	date := toml.LocalDate{Year: 1979, Month: 5, Day: 27}
+	cases := []struct {
+		query string
+		want  any
+	}{
+		{"42", int64(42)},
+		{"-42", int64(-42)},
+		{"3.14", 3.14},
+		{"-2e-3", -0.002},
+		{"1E6", 1e6},
+		{"inf", math.Inf(1)},
+		{"-inf", math.Inf(-1)},
+		{"true", true},
+		{"false", false},
+		{"'single'", "single"},
+		{"\"double\"", "double"},
+		{"1979-05-27", date},
+		{
+			"1979-05-27T07:32:00.999",
+			toml.LocalDateTime{
+				LocalDate: date,
+				LocalTime: toml.LocalTime{
+					Hour:       7,
+					Minute:     32,
+					Nanosecond: 999000000,
+					Precision:  3,
+				},
+			},
+		},
+		{"1979-05-27T07:32:00Z", time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC)},
+		{
+			"1979-05-27t00:32:00-07:00",
+			time.Date(1979, 5, 27, 0, 32, 0, 0, time.FixedZone("", -7*60*60)),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			r := strings.NewReader(c.query)
+			s, _ := scanner.New(r)
+			l, _ := lexer.New(s)
+			p, err := New(l)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, err := p.Parse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := root.Query.(*ast.Query).Filters[0].(*ast.Filter)
+			have := f.Kind.(*ast.Literal).Value
+			if !reflect.DeepEqual(have, c.want) {
+				t.Errorf("have: %#v; want: %#v", have, c.want)
+			}
+		})
+	}
+}
+
+// Verify if the NaN literal is parsed into the float NaN value.
+func TestParseLiteralNaN(t *testing.T) {
+	s, _ := scanner.New(strings.NewReader("nan"))
+	l, _ := lexer.New(s)
+	p, _ := New(l)
+	root, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := root.Query.(*ast.Query).Filters[0].(*ast.Filter)
+	if v, ok := f.Kind.(*ast.Literal).Value.(float64); !ok || !math.IsNaN(v) {
+		t.Errorf("have: %v; want: NaN", f.Kind.(*ast.Literal).Value)
 	}
 }
@@ -75,7 +75,10 @@ func (t *Tq) Run(input io.Reader, output io.Writer, query string) error {
 		return err
 	}
 	interpreter := interpreter.New()
-	exec := interpreter.Interpret(ast)
+	exec, err := interpreter.Interpret(ast)
+	if err != nil {
+		return err
+	}
 	var data any
 	err = t.adapter.Unmarshal(input, &data)
 	if err != nil {